@@ -12,6 +12,9 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/jtarrio/raildata/errors"
 )
@@ -45,15 +48,25 @@ func (m MethodDefinition[I, O]) SetToken(input *I, token string) {
 
 // Request sends an HTTP request via the given client, to the server at the provided API base URL.
 func (m MethodDefinition[I, O]) Request(ctx context.Context, client *http.Client, apiBase url.URL, input *I) (*O, error) {
+	b, err := m.RequestRaw(ctx, client, apiBase, input)
+	if err != nil {
+		return nil, err
+	}
+	return m.Decode(b)
+}
+
+// RequestRaw sends an HTTP request like [MethodDefinition.Request], but returns the raw response body
+// instead of the response object. Use [MethodDefinition.Decode] to turn it into a response object.
+func (m MethodDefinition[I, O]) RequestRaw(ctx context.Context, client *http.Client, apiBase url.URL, input *I) ([]byte, error) {
 	req, err := m.createRequest(ctx, apiBase, input)
 	if err != nil {
 		return nil, err
 	}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error issuing request for method '%s': %w", m.Name, err)
+		return nil, errors.NewTransportError(m.Name, err)
 	}
-	return m.ParseResponse(resp)
+	return m.readResponse(resp)
 }
 
 func (m MethodDefinition[I, O]) createRequest(ctx context.Context, apiBase url.URL, input *I) (*http.Request, error) {
@@ -85,6 +98,14 @@ func (m MethodDefinition[I, O]) createRequest(ctx context.Context, apiBase url.U
 }
 
 func (m MethodDefinition[I, O]) ParseResponse(response *http.Response) (*O, error) {
+	b, err := m.readResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	return m.Decode(b)
+}
+
+func (m MethodDefinition[I, O]) readResponse(response *http.Response) ([]byte, error) {
 	defer response.Body.Close()
 	if response.StatusCode < 200 || response.StatusCode >= 300 {
 		return nil, m.parseErrorResponse(response)
@@ -92,41 +113,56 @@ func (m MethodDefinition[I, O]) ParseResponse(response *http.Response) (*O, erro
 
 	b, err := io.ReadAll(response.Body)
 	if err != nil {
-		return nil, err
+		return nil, errors.NewTransportError(m.Name, err)
 	}
 	if len(b) == 0 {
 		return nil, errors.MissingCredentialsError
 	}
+	return b, nil
+}
 
+// Decode parses a raw response body into a response object.
+func (m MethodDefinition[I, O]) Decode(b []byte) (*O, error) {
 	var output O
-	err = json.Unmarshal(b, &output)
+	err := json.Unmarshal(b, &output)
 	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal response for %s: %w", m.Name, err)
+		return nil, errors.NewDecodeError(m.Name, b, err)
 	}
 	return &output, nil
 }
 
 func (m MethodDefinition[I, O]) parseErrorResponse(response *http.Response) error {
+	return errors.NewHttpStatusError(m.Name, response.StatusCode, response.Status, m.parseErrorMessage(response))
+}
+
+// parseErrorMessage returns the error described by the message in an error response, or nil if there is no message.
+func (m MethodDefinition[I, O]) parseErrorMessage(response *http.Response) error {
 	var errResp struct {
 		Message string `json:"errorMessage"`
 	}
-	ret := fmt.Errorf("received error status code for %s: %s", m.Name, response.Status)
 	b, err := io.ReadAll(response.Body)
 	if err != nil {
-		return ret
+		return nil
 	}
 	decoder := json.NewDecoder(bytes.NewReader(b))
 	decoder.DisallowUnknownFields()
 	err = decoder.Decode(&errResp)
 	if err != nil {
-		return ret
+		return nil
 	}
 	if errResp.Message == "Invalid token." {
 		return errors.InvalidTokenError
 	}
+	if match := dailyUsageRegexp.FindStringSubmatch(errResp.Message); match != nil {
+		limit, _ := strconv.Atoi(match[1])
+		usage, _ := strconv.Atoi(match[2])
+		return errors.NewQuotaExceededError(m.Name, limit, usage, time.Time{}, errors.NewRailDataError(errResp.Message))
+	}
 	return errors.NewRailDataError(errResp.Message)
 }
 
+var dailyUsageRegexp = regexp.MustCompile(`^Daily usage limit:\s*(\d+)\. Your current daily usage:\s*(\d+)`)
+
 func objToMap(i any) (map[string]string, error) {
 	b, err := json.Marshal(i)
 	if err != nil {
@@ -0,0 +1,267 @@
+// Package arrivals predicts when a train will arrive at each of its remaining stops.
+//
+// The prediction starts from the stop times returned by GetTrainStopList, which already reflect the delay known
+// to the RailData API. It then uses the train's delay and GPS position from GetVehicleData to find out whether
+// the train is running later than that, and assumes the train will recover the extra lateness by shortening
+// its dwell time at each stop. Each predicted arrival has a confidence band that widens further down the line.
+// If GetVehicleData fails, the prediction is made from the stop list alone.
+//
+// Example:
+//
+//	p := arrivals.New(client)
+//	prediction, err := p.PredictArrivals(ctx, "3887")
+//	if err != nil { return err }
+//	for _, stop := range prediction.Stops {
+//	    fmt.Println(stop.Station.Name, stop.Arrival, stop.Earliest, stop.Latest)
+//	}
+package arrivals
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jtarrio/raildata"
+)
+
+// DefaultAverageSpeed contains the default average speed of a train between stations, in meters per second.
+// It is used to estimate when the train will reach its next stop from its GPS position.
+const DefaultAverageSpeed = 15.0
+
+// DefaultMinDwell contains the default shortest time a train can spend at a station.
+// A train can recover lateness by shortening its dwell time down to this value.
+const DefaultMinDwell = 30 * time.Second
+
+// uncertainty contains the width of the confidence band for an arrival that is about to happen.
+// Predictions that aren't backed by vehicle data are less reliable, so they use staleUncertainty.
+const (
+	uncertainty      = time.Minute
+	staleUncertainty = 3 * time.Minute
+)
+
+// uncertaintyRate contains how much the confidence band widens for every minute until the predicted arrival.
+const uncertaintyRate = 0.1
+
+// Prediction contains the predicted arrival times of a train at its remaining stops.
+type Prediction struct {
+	// TrainId contains the train's number.
+	TrainId string
+	// Line contains the line this train runs on.
+	Line raildata.Line
+	// Destination contains the destination name.
+	Destination string
+	// Delay contains the train's current delay as reported by the RailData API, if known.
+	Delay *time.Duration
+	// Location contains the train's last known GPS position, if known.
+	Location *raildata.Location
+	// HasVehicleData indicates, if true, that the prediction used the train's position and delay from GetVehicleData.
+	HasVehicleData bool
+	// Stops contains the predictions for the stops the train hasn't departed yet, in order.
+	Stops []StopPrediction
+	// Updated contains the date/time this prediction was made.
+	Updated time.Time
+}
+
+// StopPrediction contains the predicted arrival time of a train at one of its stops.
+type StopPrediction struct {
+	// Station contains the station where the train stops.
+	Station raildata.Station
+	// ListedArrival contains the arrival time returned by the RailData API.
+	ListedArrival time.Time
+	// Arrival contains the predicted arrival time.
+	Arrival time.Time
+	// Earliest contains the earliest time the train is likely to arrive.
+	Earliest time.Time
+	// Latest contains the latest time the train is likely to arrive.
+	Latest time.Time
+	// ExtraDelay contains how much later than ListedArrival the train is predicted to arrive.
+	ExtraDelay time.Duration
+}
+
+// Option is the type of the options that can be passed to [New].
+type Option func(*Predictor)
+
+// WithAverageSpeed sets the average speed of a train between stations, in meters per second.
+// The default is [DefaultAverageSpeed].
+func WithAverageSpeed(speed float64) Option {
+	return func(p *Predictor) {
+		p.speed = speed
+	}
+}
+
+// WithMinDwell sets the shortest time a train can spend at a station.
+// The default is [DefaultMinDwell].
+func WithMinDwell(dwell time.Duration) Option {
+	return func(p *Predictor) {
+		p.minDwell = dwell
+	}
+}
+
+// WithLogger sets the logger that the predictor uses to report the failures it recovers from.
+// By default, nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Predictor) {
+		p.logger = logger
+	}
+}
+
+// WithClock sets the function that the predictor uses to get the current time.
+func WithClock(now func() time.Time) Option {
+	return func(p *Predictor) {
+		p.now = now
+	}
+}
+
+// Predictor predicts train arrival times using a RailData client.
+type Predictor struct {
+	client   raildata.Client
+	speed    float64
+	minDwell time.Duration
+	now      func() time.Time
+	logger   *slog.Logger
+}
+
+// New creates a [Predictor] that uses the given client.
+func New(client raildata.Client, options ...Option) *Predictor {
+	p := &Predictor{
+		client:   client,
+		speed:    DefaultAverageSpeed,
+		minDwell: DefaultMinDwell,
+		now:      time.Now,
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
+}
+
+// stop contains the listed times of one of the train's remaining stops.
+type stop struct {
+	station   raildata.Station
+	arrival   time.Time
+	departure time.Time
+}
+
+// PredictArrivals returns the predicted arrival times of a train at each of the stops it hasn't departed yet.
+// It returns a [github.com/jtarrio/raildata/errors.TrainNotFoundError] if the RailData API doesn't know about the train.
+func (p *Predictor) PredictArrivals(ctx context.Context, trainId string) (*Prediction, error) {
+	stopList, err := p.client.GetTrainStopList(ctx, &raildata.GetTrainStopListRequest{TrainId: trainId})
+	if err != nil {
+		return nil, err
+	}
+	var vehicle *raildata.VehicleData
+	if vehicles, err := p.client.GetVehicleData(ctx); err != nil {
+		p.logger.Warn("vehicle data not available", "train", trainId, "error", err.Error())
+	} else if i := slices.IndexFunc(vehicles.Vehicles, func(v raildata.VehicleData) bool { return v.TrainId == trainId }); i >= 0 {
+		vehicle = &vehicles.Vehicles[i]
+	}
+
+	now := p.now()
+	prediction := &Prediction{
+		TrainId:     stopList.TrainId,
+		Line:        stopList.Line,
+		Destination: stopList.Destination,
+		Updated:     now,
+	}
+	if vehicle != nil {
+		prediction.Delay = vehicle.Delay
+		prediction.Location = vehicle.Location
+		prediction.HasVehicleData = true
+	}
+
+	stops := remainingStops(stopList.Stops)
+	if len(stops) == 0 {
+		return prediction, nil
+	}
+	extra := p.extraDelay(vehicle, &stops[0])
+	for i := range stops {
+		s := &stops[i]
+		arrival := s.arrival.Add(extra)
+		spread := staleUncertainty
+		if vehicle != nil {
+			spread = uncertainty
+		}
+		if ahead := arrival.Sub(now); ahead > 0 {
+			spread += time.Duration(float64(ahead) * uncertaintyRate)
+		}
+		prediction.Stops = append(prediction.Stops, StopPrediction{
+			Station:       s.station,
+			ListedArrival: s.arrival,
+			Arrival:       arrival,
+			Earliest:      earlier(arrival, later(now, arrival.Add(-spread/2))),
+			Latest:        arrival.Add(spread),
+			ExtraDelay:    extra,
+		})
+		// The train makes up time by spending less time at the station.
+		recoverable := max(0, s.departure.Sub(s.arrival)-p.minDwell)
+		extra = max(0, extra-recoverable)
+		if i == 0 {
+			// The train can't leave its next stop before the current time.
+			extra = max(extra, now.Sub(s.departure))
+		}
+	}
+	return prediction, nil
+}
+
+// extraDelay returns how much later than the listed time the train will arrive at its next stop,
+// according to the vehicle data.
+func (p *Predictor) extraDelay(vehicle *raildata.VehicleData, next *stop) time.Duration {
+	var extra time.Duration
+	if vehicle == nil {
+		return extra
+	}
+	if vehicle.Delay != nil && !vehicle.DepartureTime.IsZero() && vehicle.NextStop.Code == next.station.Code {
+		// The vehicle data contains the scheduled departure time from the next stop and the current delay.
+		// If the stop list doesn't reflect the delay yet, the difference is extra delay.
+		extra = max(extra, vehicle.DepartureTime.Add(*vehicle.Delay).Sub(next.departure))
+	}
+	if vehicle.Location != nil && !vehicle.LastUpdated.IsZero() && p.speed > 0 {
+		if details, found := raildata.GetStationDetails(next.station.Code); found {
+			travel := time.Duration(vehicle.Location.DistanceTo(details.Location) / p.speed * float64(time.Second))
+			extra = max(extra, vehicle.LastUpdated.Add(travel).Sub(next.arrival))
+		}
+	}
+	return extra
+}
+
+// remainingStops returns the stops the train hasn't departed yet, skipping the cancelled ones
+// and the ones without any listed time.
+func remainingStops(stops []raildata.TrainStop) []stop {
+	var out []stop
+	for i := range stops {
+		s := &stops[i]
+		if s.Departed || s.IsCancelled() {
+			continue
+		}
+		var arrival, departure time.Time
+		switch {
+		case s.ArrivalTime != nil && s.DepartureTime != nil:
+			arrival, departure = *s.ArrivalTime, *s.DepartureTime
+		case s.ArrivalTime != nil:
+			arrival, departure = *s.ArrivalTime, *s.ArrivalTime
+		case s.DepartureTime != nil:
+			arrival, departure = *s.DepartureTime, *s.DepartureTime
+		default:
+			continue
+		}
+		out = append(out, stop{station: s.Station, arrival: arrival, departure: departure})
+	}
+	return out
+}
+
+func later(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a time.Time, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
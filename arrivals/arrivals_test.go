@@ -0,0 +1,173 @@
+package arrivals_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/arrivals"
+	"github.com/jtarrio/raildata/errors"
+	"github.com/jtarrio/raildata/raildatatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTrain returns a train that has left Trenton (TR) and Princeton Junction (PJ) and will stop next at New Brunswick (NB).
+func newTrain() raildatatest.Train {
+	return raildatatest.Train{
+		TrainId:   "3887",
+		Line:      raildatatest.Line("NE"),
+		Direction: raildata.DirectionEastbound,
+		Status:    "On Time",
+		Stops: []raildatatest.Stop{
+			{Station: raildatatest.Station("TR"), Time: raildatatest.At(-60), Dwell: time.Minute, Departed: true},
+			{Station: raildatatest.Station("PJ"), Time: raildatatest.At(-45), Dwell: time.Minute, Departed: true},
+			{Station: raildatatest.Station("NB"), Time: raildatatest.At(10), Dwell: 2 * time.Minute},
+			{Station: raildatatest.Station("MP"), Time: raildatatest.At(25), Dwell: time.Minute},
+			{Station: raildatatest.Station("NP"), Time: raildatatest.At(45), Dwell: 3 * time.Minute},
+			{Station: raildatatest.Station("NY"), Time: raildatatest.At(60)},
+		},
+	}
+}
+
+func newPredictor(t *testing.T, server *raildatatest.Server, now time.Time, options ...arrivals.Option) *arrivals.Predictor {
+	return arrivals.New(server.NewClient(t), append([]arrivals.Option{arrivals.WithClock(func() time.Time { return now })}, options...)...)
+}
+
+func stationCodes(prediction *arrivals.Prediction) []raildata.StationCode {
+	var out []raildata.StationCode
+	for _, stop := range prediction.Stops {
+		out = append(out, stop.Station.Code)
+	}
+	return out
+}
+
+func TestPredictArrivalsWithoutVehicleData(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	server.AddTrain(newTrain())
+	p := newPredictor(t, server, raildatatest.At(0))
+
+	prediction, err := p.PredictArrivals(context.Background(), "3887")
+	require.NoError(t, err)
+	assert.Equal(t, "3887", prediction.TrainId)
+	assert.Equal(t, raildata.LineCode("NE"), prediction.Line.Code)
+	assert.False(t, prediction.HasVehicleData)
+	assert.Equal(t, []raildata.StationCode{"NB", "MP", "NP", "NY"}, stationCodes(prediction))
+
+	for _, stop := range prediction.Stops {
+		assert.WithinDuration(t, stop.ListedArrival, stop.Arrival, 0)
+		assert.Zero(t, stop.ExtraDelay)
+	}
+	// The confidence band is 3 minutes plus 10% of the time until the arrival.
+	nb := prediction.Stops[0]
+	assert.WithinDuration(t, raildatatest.At(10), nb.Arrival, 0)
+	assert.WithinDuration(t, raildatatest.At(10).Add(-120*time.Second), nb.Earliest, 0)
+	assert.WithinDuration(t, raildatatest.At(14), nb.Latest, 0)
+	ny := prediction.Stops[3]
+	assert.WithinDuration(t, raildatatest.At(69), ny.Latest, 0)
+}
+
+func TestPredictArrivalsWithDelay(t *testing.T) {
+	server := raildatatest.NewServer(raildatatest.WithClock(func() time.Time { return raildatatest.At(0) }))
+	defer server.Close()
+	train := newTrain()
+	train.Delay = 5 * time.Minute
+	train.Location = &raildata.Location{Latitude: 40.4900, Longitude: -74.4500}
+	server.AddTrain(train)
+	p := newPredictor(t, server, raildatatest.At(0))
+
+	prediction, err := p.PredictArrivals(context.Background(), "3887")
+	require.NoError(t, err)
+	assert.True(t, prediction.HasVehicleData)
+	require.NotNil(t, prediction.Delay)
+	assert.Equal(t, 5*time.Minute, *prediction.Delay)
+	require.NotNil(t, prediction.Location)
+
+	// The stop list already includes the delay, and the train is close to its next stop.
+	nb := prediction.Stops[0]
+	assert.WithinDuration(t, raildatatest.At(15), nb.ListedArrival, 0)
+	assert.WithinDuration(t, raildatatest.At(15), nb.Arrival, 0)
+	assert.Zero(t, nb.ExtraDelay)
+	assert.WithinDuration(t, raildatatest.At(15).Add(-75*time.Second), nb.Earliest, 0)
+	assert.WithinDuration(t, raildatatest.At(15).Add(150*time.Second), nb.Latest, 0)
+}
+
+func TestPredictArrivalsFromGpsPosition(t *testing.T) {
+	server := raildatatest.NewServer(raildatatest.WithClock(func() time.Time { return raildatatest.At(0) }))
+	defer server.Close()
+	train := newTrain()
+	// The train is still at Princeton Junction, about 25 km from New Brunswick.
+	train.Location = &raildata.Location{Latitude: 40.3166, Longitude: -74.6236}
+	server.AddTrain(train)
+	p := newPredictor(t, server, raildatatest.At(0))
+
+	prediction, err := p.PredictArrivals(context.Background(), "3887")
+	require.NoError(t, err)
+	require.Len(t, prediction.Stops, 4)
+
+	nb := prediction.Stops[0]
+	pj, _ := raildata.GetStationDetails("PJ")
+	nbDetails, _ := raildata.GetStationDetails("NB")
+	travel := time.Duration(pj.Location.DistanceTo(nbDetails.Location) / arrivals.DefaultAverageSpeed * float64(time.Second))
+	assert.WithinDuration(t, raildatatest.At(0).Add(travel), nb.Arrival, time.Millisecond)
+	assert.Greater(t, nb.ExtraDelay, 15*time.Minute)
+
+	// The train recovers some time by shortening its dwell times.
+	assert.Equal(t, nb.ExtraDelay-90*time.Second, prediction.Stops[1].ExtraDelay)
+	assert.Equal(t, nb.ExtraDelay-2*time.Minute, prediction.Stops[2].ExtraDelay)
+	assert.Equal(t, nb.ExtraDelay-(4*time.Minute+30*time.Second), prediction.Stops[3].ExtraDelay)
+	for i := 1; i < len(prediction.Stops); i++ {
+		prev, stop := prediction.Stops[i-1], prediction.Stops[i]
+		assert.Greater(t, stop.Latest.Sub(stop.Arrival), prev.Latest.Sub(prev.Arrival))
+	}
+}
+
+func TestPredictArrivalsAtStation(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	server.AddTrain(newTrain())
+	// New Brunswick's listed departure was 3 minutes ago, but the train hasn't left yet.
+	p := newPredictor(t, server, raildatatest.At(15))
+
+	prediction, err := p.PredictArrivals(context.Background(), "3887")
+	require.NoError(t, err)
+	assert.Zero(t, prediction.Stops[0].ExtraDelay)
+	assert.WithinDuration(t, raildatatest.At(10), prediction.Stops[0].Earliest, 0)
+	assert.Equal(t, 3*time.Minute, prediction.Stops[1].ExtraDelay)
+	assert.WithinDuration(t, raildatatest.At(28), prediction.Stops[1].Arrival, 0)
+}
+
+func TestPredictArrivalsWhenVehicleDataFails(t *testing.T) {
+	server := raildatatest.NewServer(raildatatest.WithClock(func() time.Time { return raildatatest.At(0) }))
+	defer server.Close()
+	train := newTrain()
+	train.Location = &raildata.Location{Latitude: 40.3166, Longitude: -74.6236}
+	server.AddTrain(train)
+	server.InjectFault("getVehicleData", raildatatest.Fault{StatusCode: 500, Count: 1})
+	var logs bytes.Buffer
+	p := newPredictor(t, server, raildatatest.At(0), arrivals.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+	prediction, err := p.PredictArrivals(context.Background(), "3887")
+	require.NoError(t, err)
+	assert.False(t, prediction.HasVehicleData)
+	assert.Nil(t, prediction.Location)
+	assert.Equal(t, []raildata.StationCode{"NB", "MP", "NP", "NY"}, stationCodes(prediction))
+	assert.Zero(t, prediction.Stops[0].ExtraDelay)
+	assert.Contains(t, logs.String(), "vehicle data not available")
+	assert.Contains(t, logs.String(), "train=3887")
+}
+
+func TestPredictArrivalsTrainNotFound(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	p := newPredictor(t, server, raildatatest.At(0))
+
+	_, err := p.PredictArrivals(context.Background(), "9999")
+	var notFound *errors.TrainNotFoundError
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, "9999", notFound.TrainId)
+}
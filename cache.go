@@ -0,0 +1,168 @@
+package raildata
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jtarrio/raildata/api"
+)
+
+// Cache stores raw RailData API responses so they can be reused by later requests.
+//
+// Entries are keyed by the method name and the request fields (without the token).
+// Implementations must be safe for concurrent use. They may return entries that have already expired;
+// the client checks the expiration time before using an entry.
+type Cache interface {
+	// Get returns the entry stored under the given key, or false if there is none.
+	Get(key string) (*CacheEntry, bool)
+	// Put stores an entry under the given key, replacing any previous entry.
+	Put(key string, entry *CacheEntry)
+}
+
+// CacheEntry contains a cached API response.
+type CacheEntry struct {
+	// Data contains the raw response body.
+	Data []byte
+	// Created contains the date/time the response was received from the server.
+	Created time.Time
+	// Expires contains the date/time after which the response must not be used.
+	Expires time.Time
+}
+
+// DefaultCacheTTLs contains the default time-to-live of cached responses for each RailData API method.
+// Responses for methods that are not listed here are not cached.
+var DefaultCacheTTLs = map[string]time.Duration{
+	api.GetStationList.Name:        24 * time.Hour,
+	api.GetStationMSG.Name:         1 * time.Minute,
+	api.GetStationSchedule.Name:    24 * time.Hour,
+	api.GetTrainSchedule.Name:      30 * time.Second,
+	api.GetTrainSchedule19Rec.Name: 30 * time.Second,
+	api.GetTrainStopList.Name:      30 * time.Second,
+	api.GetVehicleData.Name:        10 * time.Second,
+}
+
+// NewMemoryCache returns a [Cache] that keeps up to maxEntries responses in memory.
+// When the cache is full, the least recently used entry is discarded.
+func NewMemoryCache(maxEntries int) Cache {
+	return &memoryCache{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+type memoryCache struct {
+	mutex      sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+func (c *memoryCache) Get(key string) (*CacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elem, found := c.entries[key]
+	if !found {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*memoryCacheItem).entry, true
+}
+
+func (c *memoryCache) Put(key string, entry *CacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if elem, found := c.entries[key]; found {
+		elem.Value.(*memoryCacheItem).entry = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&memoryCacheItem{key: key, entry: entry})
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+// NewFileCache returns a [Cache] that stores each response in a file in the given directory.
+// The directory is created if it doesn't exist.
+//
+// Several processes can share the same directory.
+func NewFileCache(dir string) (Cache, error) {
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, err
+	}
+	return &fileCache{dir: dir}, nil
+}
+
+type fileCache struct {
+	dir string
+}
+
+func (c *fileCache) Get(key string) (*CacheEntry, bool) {
+	b, err := os.ReadFile(c.fileName(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *fileCache) Put(key string, entry *CacheEntry) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	f, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(b)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.fileName(key))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+}
+
+func (c *fileCache) fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func cacheKey[I any, O any](method api.MethodDefinition[I, O], input *I) (string, error) {
+	redacted := *input
+	method.SetToken(&redacted, "")
+	b, err := json.Marshal(&redacted)
+	if err != nil {
+		return "", err
+	}
+	return method.Name + ":" + string(b), nil
+}
+
+func (s *raildataClient) cacheTTL(method string) time.Duration {
+	if ttl, found := s.cacheTTLs[method]; found {
+		return ttl
+	}
+	return DefaultCacheTTLs[method]
+}
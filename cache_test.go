@@ -0,0 +1,114 @@
+package raildata_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := raildata.NewMemoryCache(2)
+	cache.Put("a", &raildata.CacheEntry{Data: []byte("A")})
+	cache.Put("b", &raildata.CacheEntry{Data: []byte("B")})
+	_, found := cache.Get("a")
+	assert.True(t, found)
+	cache.Put("c", &raildata.CacheEntry{Data: []byte("C")})
+
+	_, found = cache.Get("b")
+	assert.False(t, found)
+	entry, found := cache.Get("a")
+	assert.True(t, found)
+	assert.Equal(t, []byte("A"), entry.Data)
+	entry, found = cache.Get("c")
+	assert.True(t, found)
+	assert.Equal(t, []byte("C"), entry.Data)
+}
+
+func TestFileCache(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := raildata.NewFileCache(dir)
+	require.NoError(t, err)
+	created := time.Date(2025, time.January, 17, 21, 49, 33, 0, time.UTC)
+	cache.Put("key", &raildata.CacheEntry{Data: []byte("data"), Created: created, Expires: created.Add(time.Minute)})
+
+	other, err := raildata.NewFileCache(dir)
+	require.NoError(t, err)
+	entry, found := other.Get("key")
+	assert.True(t, found)
+	assert.Equal(t, []byte("data"), entry.Data)
+	assert.True(t, created.Equal(entry.Created))
+	assert.True(t, created.Add(time.Minute).Equal(entry.Expires))
+
+	_, found = other.Get("other-key")
+	assert.False(t, found)
+}
+
+func TestCachedResponse(t *testing.T) {
+	calls := 0
+	handler := expectRequest(t, "getStationList").sendJson(`[{"STATION_2CHAR": "AM", "STATIONNAME": "Aberdeen-Matawan", "STATION_14CHAR": "Matawan"}]`)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		handler.ServeHTTP(rw, req)
+	}))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithCache(raildata.NewMemoryCache(10)))
+	require.NoError(t, err)
+
+	first, err := client.GetStationList(context.Background())
+	require.NoError(t, err)
+	assert.False(t, first.Info.Cached)
+	second, err := client.GetStationList(context.Background())
+	require.NoError(t, err)
+	assert.True(t, second.Info.Cached)
+	assert.Equal(t, first.Stations, second.Stations)
+	assert.Equal(t, 1, calls)
+}
+
+func TestCacheTTLCanBeChanged(t *testing.T) {
+	calls := 0
+	handler := expectRequest(t, "getStationList").sendJson(`[]`)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		handler.ServeHTTP(rw, req)
+	}))
+
+	client, err := raildata.NewClient(
+		withServerUrl(t, server),
+		raildata.WithToken(testToken),
+		raildata.WithCache(raildata.NewMemoryCache(10)),
+		raildata.WithCacheTTL("getStationList", 0))
+	require.NoError(t, err)
+
+	for range 2 {
+		actual, err := client.GetStationList(context.Background())
+		require.NoError(t, err)
+		assert.False(t, actual.Info.Cached)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestCacheKeyIncludesRequestFields(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		require.NoError(t, req.ParseMultipartForm(5000000))
+		rw.Write([]byte(`{"STATION_2CHAR": "` + req.Form.Get("station") + `", "STATIONNAME": "", "STATIONMSGS": null, "ITEMS": null}`))
+	}))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithCache(raildata.NewMemoryCache(10)))
+	require.NoError(t, err)
+
+	ny, err := client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+	require.NoError(t, err)
+	np, err := client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NP"})
+	require.NoError(t, err)
+	assert.Equal(t, raildata.StationCode("NY"), ny.Station.Code)
+	assert.Equal(t, raildata.StationCode("NP"), np.Station.Code)
+	assert.Equal(t, 2, calls)
+}
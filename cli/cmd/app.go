@@ -3,11 +3,8 @@ package cmd
 import (
 	"context"
 	"errors"
-	"os"
-	"strings"
 
 	"github.com/jtarrio/raildata"
-	"github.com/rogpeppe/go-internal/lockedfile"
 	"github.com/urfave/cli/v2"
 )
 
@@ -31,6 +28,10 @@ func App() *cli.App {
 				Usage:   "the RailData API password",
 				EnvVars: []string{"RAILDATA_PASSWORD"},
 			},
+			&cli.StringFlag{
+				Name:  "quotafile",
+				Usage: "the pathname of a file where the number of calls to rate-limited methods is recorded",
+			},
 			&cli.BoolFlag{
 				Name:  "use-test-endpoint",
 				Usage: "use the RailData test endpoint",
@@ -38,6 +39,7 @@ func App() *cli.App {
 		},
 		Before: createClient,
 		Commands: []*cli.Command{
+			cmdCheckSchema,
 			cmdGetStationMsg,
 			cmdGetStationSchedule,
 			cmdGetTrainSchedule,
@@ -54,13 +56,11 @@ func createClient(ctx *cli.Context) error {
 	if ctx.Bool("use-test-endpoint") {
 		options = append(options, raildata.WithTestEndpoint(true))
 	}
-	tokenfile := ctx.String("tokenfile")
-	token, err := readTokenFile(tokenfile)
-	if err != nil {
-		return err
+	options = append(options, raildata.WithTokenStore(raildata.NewFileTokenStore(ctx.String("tokenfile"))))
+
+	if quotafile := ctx.String("quotafile"); len(quotafile) > 0 {
+		options = append(options, raildata.WithQuotaStore(raildata.NewFileQuotaStore(quotafile)))
 	}
-	options = append(options, raildata.WithToken(token))
-	options = append(options, raildata.WithTokenUpdateListener(tokenFileUpdater(tokenfile)))
 
 	username := ctx.String("username")
 	password := ctx.String("password")
@@ -71,39 +71,26 @@ func createClient(ctx *cli.Context) error {
 		options = append(options, raildata.WithCredentials(username, password))
 	}
 
+	monitor := raildata.NewSchemaMonitor(nil)
+	options = append(options, raildata.WithSchemaMonitor(monitor))
+
 	client, err := raildata.NewClient(options...)
 	if err != nil {
 		return err
 	}
 	ctx.Context = context.WithValue(ctx.Context, clientKey, client)
+	ctx.Context = context.WithValue(ctx.Context, schemaMonitorKey, monitor)
 	return nil
 }
 
-func readTokenFile(name string) (string, error) {
-	b, err := os.ReadFile(name)
-	if err != nil {
-		return "", err
-	}
-	token, _, _ := strings.Cut(string(b), "\n")
-	return token, nil
-}
-
-func tokenFileUpdater(name string) raildata.TokenUpdateListener {
-	return func(newToken string, oldToken string) {
-		_ = lockedfile.Transform(name, func(old []byte) ([]byte, error) {
-			token, _, _ := strings.Cut(string(old), "\n")
-			if token != oldToken {
-				return old, errors.New("")
-			}
-			return []byte(newToken + "\n"), nil
-		})
-	}
-}
-
 type clientKeyType struct{}
 
 var clientKey = clientKeyType{}
 
+type schemaMonitorKeyType struct{}
+
+var schemaMonitorKey = schemaMonitorKeyType{}
+
 func GetClientFromContext(ctx context.Context) raildata.Client {
 	value := ctx.Value(clientKey)
 	if o, ok := value.(raildata.Client); ok {
@@ -111,3 +98,11 @@ func GetClientFromContext(ctx context.Context) raildata.Client {
 	}
 	panic("No client found in context")
 }
+
+func GetSchemaMonitorFromContext(ctx context.Context) *raildata.SchemaMonitor {
+	value := ctx.Value(schemaMonitorKey)
+	if o, ok := value.(*raildata.SchemaMonitor); ok {
+		return o
+	}
+	panic("No schema monitor found in context")
+}
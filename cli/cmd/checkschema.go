@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/raildata-cli/util"
+	"github.com/urfave/cli/v2"
+)
+
+var cmdCheckSchema = &cli.Command{
+	Name:  "checkSchema",
+	Usage: "calls the methods that are not rate-limited and reports differences between the responses and the expected schema",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "station",
+			Usage: "code or name of a station to get the schedule for",
+			Value: "NY",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		return checkSchema(ctx.Context, ctx.String("station"))
+	},
+}
+
+func checkSchema(ctx context.Context, station string) error {
+	stationCode, found := util.FindStation(station)
+	if !found {
+		return fmt.Errorf("station '%s' unknown", station)
+	}
+	client := GetClientFromContext(ctx)
+	monitor := GetSchemaMonitorFromContext(ctx)
+
+	var errs []error
+	_, err := client.GetStationList(ctx)
+	errs = append(errs, err)
+	_, err = client.GetStationMsg(ctx, &raildata.GetStationMsgRequest{})
+	errs = append(errs, err)
+	_, err = client.GetTrainSchedule19Records(ctx, &raildata.GetTrainSchedule19RecordsRequest{StationCode: *stationCode})
+	errs = append(errs, err)
+	schedule, err := client.GetTrainSchedule(ctx, &raildata.GetTrainScheduleRequest{StationCode: *stationCode})
+	errs = append(errs, err)
+	if schedule != nil && len(schedule.Entries) > 0 {
+		_, err = client.GetTrainStopList(ctx, &raildata.GetTrainStopListRequest{TrainId: schedule.Entries[0].TrainId})
+		errs = append(errs, err)
+	}
+	_, err = client.GetVehicleData(ctx)
+	errs = append(errs, err)
+
+	for _, err := range errs {
+		if err != nil {
+			color.New(color.FgHiRed).Printf("Error: %s\n", err)
+		}
+	}
+
+	for i, report := range monitor.Report() {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s (%d responses)\n", report.Method, report.Responses)
+		if len(report.Drift) == 0 {
+			fmt.Println("  No differences found")
+		}
+		for _, drift := range report.Drift {
+			switch drift.Kind {
+			case raildata.SchemaDriftUnknownField:
+				color.New(color.FgYellow).Printf("  %s: %s, example: %s\n", drift.Field, drift.Kind, drift.Example)
+			case raildata.SchemaDriftTypeChanged:
+				color.New(color.FgHiRed).Printf("  %s: %s from %s to %s, example: %s\n", drift.Field, drift.Kind, drift.Expected, drift.Actual, drift.Example)
+			default:
+				fmt.Printf("  %s: %s\n", drift.Field, drift.Kind)
+			}
+		}
+	}
+	return nil
+}
@@ -136,6 +136,20 @@ func displayStops(stops []raildata.TrainStop) {
 	fmt.Println()
 }
 
+func capacityTrend(trend *raildata.CapacityTrend) string {
+	if trend == nil {
+		return ""
+	}
+	switch trend.Direction {
+	case raildata.TrendFilling:
+		return fmt.Sprintf(", filling (+%d pax)", trend.PassengerDelta)
+	case raildata.TrendEmptying:
+		return fmt.Sprintf(", emptying (%d pax)", trend.PassengerDelta)
+	default:
+		return ", steady"
+	}
+}
+
 func displayCapacity(capacity []raildata.TrainCapacity) {
 	fmt.Print("Capacity: ")
 	for i := range capacity {
@@ -143,9 +157,10 @@ func displayCapacity(capacity []raildata.TrainCapacity) {
 			fmt.Print(", ")
 		}
 		cap := &capacity[i]
-		fmt.Printf("Vehicle %s (%f, %f) %s\n",
+		fmt.Printf("Vehicle %s (%f, %f) %s%s\n",
 			cap.Number, cap.Location.Latitude, cap.Location.Longitude,
-			util.HtmlColors(&cap.CapacityColor, nil).Sprintf("%d%% full (%d pax)", cap.CapacityPercent, cap.PassengerCount))
+			util.HtmlColors(&cap.CapacityColor, nil).Sprintf("%d%% full (%d pax)", cap.CapacityPercent, cap.PassengerCount),
+			capacityTrend(cap.Trend))
 		cars := []*raildata.TrainCar{}
 		for s := range cap.Sections {
 			sec := &cap.Sections[s]
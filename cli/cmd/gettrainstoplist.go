@@ -33,10 +33,6 @@ func getTrainStopList(ctx context.Context, train string) error {
 	if err != nil {
 		return err
 	}
-	if resp == nil {
-		fmt.Printf("Train %s not found\n", train)
-		return nil
-	}
 
 	util.HtmlColors(&resp.Color.Foreground, &resp.Color.Background).Print(resp.Line.Name)
 	fmt.Printf("\nTrain %s for %s\n", resp.TrainId, resp.Destination)
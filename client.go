@@ -3,9 +3,13 @@ package raildata
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jtarrio/raildata/api"
 	rderrors "github.com/jtarrio/raildata/errors"
@@ -17,22 +21,15 @@ import (
 // to provide either the token or the credentials to use to generate this token.
 //
 // Note that you can only generate 5 tokens per day, so we highly recommend to save the current token
-// so you can pass it in the next call to NewClient.
+// so you can use it in the next session. The easiest way to do that is to use a [TokenStore].
 //
 // Example:
 //
-//	// Read the token from a file
-//	token, err := io.ReadFile("/path/to/token-file")
-//	if err != nil { return err }
 //	client, err := raildata.NewClient(
-//		// Provide the token to the client so it doesn't need to get a new one
-//		raildata.WithToken(string(token)),
+//		// Read the token from a file, and save it to the same file when it changes
+//		raildata.WithTokenStore(raildata.NewFileTokenStore("/path/to/token-file")),
 //		// Provide the username and password to the client so it can get a new token if the old one expires
 //		raildata.WithCredentials(username, password),
-//		// If the token changes, save it to the file so we can use it in the future
-//		raildata.WithTokenUpdateListener(func(newToken string, oldToken string) {
-//			_ = io.WriteFile("/path/to/token-file", []byte(newToken))
-//		}),
 //	)
 func NewClient(options ...Option) (Client, error) {
 	s := &raildataClient{
@@ -42,6 +39,34 @@ func NewClient(options ...Option) (Client, error) {
 	for _, opt := range options {
 		opt(s)
 	}
+	if s.logger == nil {
+		s.logger = slog.New(discardHandler{})
+	}
+	if s.parseOptions.Logger == nil {
+		s.parseOptions.Logger = s.logger
+	}
+	if s.cache == nil && s.maxStaleness > 0 {
+		s.staleEntries = NewMemoryCache(DefaultStaleEntries)
+	}
+	if s.quota.store == nil {
+		s.quota.store = NewMemoryQuotaStore()
+	}
+	if err := s.rateLimiter.validate(); err != nil {
+		return nil, err
+	}
+	if s.tokenStore != nil {
+		token, err := s.tokenStore.Load()
+		if err != nil {
+			return nil, err
+		}
+		if len(token) > 0 {
+			s.token = token
+		}
+	}
+	if s.proactiveRefreshCtx != nil {
+		s.tokenChanged = make(chan struct{}, 1)
+		go s.runProactiveTokenRefresh(s.proactiveRefreshCtx, s.proactiveRefreshMargin)
+	}
 	return s, nil
 }
 
@@ -95,18 +120,72 @@ func WithHttpClient(client *http.Client) Option {
 	}
 }
 
+// WithCache sets a cache to store API responses in, so identical requests can be served without contacting the server.
+//
+// Each response is kept for a method-specific time that is taken from [DefaultCacheTTLs] unless it is changed with [WithCacheTTL].
+// You can tell cached responses apart by looking at the Info field in the response.
+func WithCache(cache Cache) Option {
+	return func(s *raildataClient) {
+		s.cache = cache
+	}
+}
+
+// WithCacheTTL sets how long responses for the given method are kept in the cache.
+// A zero or negative value disables caching for that method.
+func WithCacheTTL(method string, ttl time.Duration) Option {
+	return func(s *raildataClient) {
+		if s.cacheTTLs == nil {
+			s.cacheTTLs = map[string]time.Duration{}
+		}
+		s.cacheTTLs[method] = ttl
+	}
+}
+
+// WithParseOptions sets the options used to parse the responses from the RailData API.
+//
+// In the default, lenient mode, fields that can't be parsed are left empty and reported in the Info field of the response.
+// In strict mode, the methods return a [github.com/jtarrio/raildata/errors.ParseError] instead.
+func WithParseOptions(options ParseOptions) Option {
+	return func(s *raildataClient) {
+		s.parseOptions = options
+	}
+}
+
 type credentials struct {
 	username string
 	password string
 }
 
 type raildataClient struct {
-	credentials          *credentials
-	apiBase              url.URL
-	client               *http.Client
-	token                string
-	tokenMutex           sync.Mutex
-	tokenUpdateListeners []TokenUpdateListener
+	credentials            *credentials
+	apiBase                url.URL
+	client                 *http.Client
+	token                  string
+	tokenMutex             sync.Mutex
+	tokenRefresh           *tokenRefresh
+	tokenIssuedAt          time.Time
+	tokenLastValidated     time.Time
+	tokenLifetime          time.Duration
+	tokenChanged           chan struct{}
+	inflight               atomic.Int32
+	tokenUpdateListeners   []TokenUpdateListener
+	tokenStore             TokenStore
+	cache                  Cache
+	cacheTTLs              map[string]time.Duration
+	quota                  quotaTracker
+	retryPolicy            RetryPolicy
+	methodRetryPolicies    map[string]RetryPolicy
+	proactiveRefreshCtx    context.Context
+	proactiveRefreshMargin time.Duration
+	parseOptions           ParseOptions
+	schemaMonitor          *SchemaMonitor
+	interceptors           []Interceptor
+	logger                 *slog.Logger
+	tracer                 Tracer
+	rateLimiter            *RateLimiter
+	coalescer              *coalescer
+	maxStaleness           time.Duration
+	staleEntries           Cache
 }
 
 func (s *raildataClient) RateLimitedMethods() RateLimitedMethods {
@@ -120,94 +199,35 @@ func (s *raildataClient) GetToken() string {
 }
 
 func (s *raildataClient) IsValidToken(ctx context.Context) (*IsValidTokenResponse, error) {
-	output, err := request(api.IsValidToken, s, ctx, &api.TokenRequest{})
-	if err != nil {
-		return nil, err
-	}
-	return ParseValidTokenResponse(output)
+	return enriched(s.Raw().IsValidToken(ctx))
 }
 
 func (s *raildataClient) GetStationList(ctx context.Context) (*GetStationListResponse, error) {
-	output, err := request(api.GetStationList, s, ctx, &api.TokenRequest{})
-	if err != nil {
-		return nil, err
-	}
-	return ParseGetStationsList(*output)
+	return enriched(s.Raw().GetStationList(ctx))
 }
 
 func (s *raildataClient) GetStationMsg(ctx context.Context, req *GetStationMsgRequest) (*GetStationMsgResponse, error) {
-	input := &api.GetStationMsgRequest{}
-	if req.LineCode != nil {
-		input.Line = string(*req.LineCode)
-	}
-	if req.StationCode != nil {
-		input.Station = string(*req.StationCode)
-	}
-	output, err := request(api.GetStationMSG, s, ctx, input)
-	if err != nil {
-		return nil, err
-	}
-	return ParseStationMsgsList(*output), nil
+	return enriched(s.Raw().GetStationMsg(ctx, req))
 }
 
 func (s *raildataClient) GetStationSchedule(ctx context.Context, req *GetStationScheduleRequest) (*GetStationScheduleResponse, error) {
-	input := &api.GetStationScheduleRequest{
-		Station: string(req.StationCode),
-	}
-	if req.NjtOnly {
-		input.NjtOnly = "true"
-	} else {
-		input.NjtOnly = "false"
-	}
-	output, err := request(api.GetStationSchedule, s, ctx, input)
-	if err != nil {
-		return nil, err
-	}
-	return ParseDailyStationInfoList(*output)
+	return enriched(s.Raw().GetStationSchedule(ctx, req))
 }
 
 func (s *raildataClient) GetTrainSchedule(ctx context.Context, req *GetTrainScheduleRequest) (*GetTrainScheduleResponse, error) {
-	input := &api.GetTrainScheduleRequest{
-		Station: string(req.StationCode),
-	}
-	output, err := request(api.GetTrainSchedule, s, ctx, input)
-	if err != nil {
-		return nil, err
-	}
-	return ParseStationInfo(output), nil
+	return enriched(s.Raw().GetTrainSchedule(ctx, req))
 }
 
 func (s *raildataClient) GetTrainSchedule19Records(ctx context.Context, req *GetTrainSchedule19RecordsRequest) (*GetTrainScheduleResponse, error) {
-	input := &api.GetTrainSchedule19RecRequest{
-		Station: string(req.StationCode),
-	}
-	if req.LineCode != nil {
-		input.Line = string(*req.LineCode)
-	}
-	output, err := request(api.GetTrainSchedule19Rec, s, ctx, input)
-	if err != nil {
-		return nil, err
-	}
-	return ParseStationInfo(output), nil
+	return enriched(s.Raw().GetTrainSchedule19Records(ctx, req))
 }
 
 func (s *raildataClient) GetTrainStopList(ctx context.Context, req *GetTrainStopListRequest) (*GetTrainStopListResponse, error) {
-	input := &api.GetTrainStopListRequest{
-		Train: req.TrainId,
-	}
-	output, err := request(api.GetTrainStopList, s, ctx, input)
-	if err != nil {
-		return nil, err
-	}
-	return ParseStops(output), nil
+	return enriched(s.Raw().GetTrainStopList(ctx, req))
 }
 
 func (s *raildataClient) GetVehicleData(ctx context.Context) (*GetVehicleDataResponse, error) {
-	output, err := request(api.GetVehicleData, s, ctx, &api.TokenRequest{})
-	if err != nil {
-		return nil, err
-	}
-	return ParseVehicleDataInfoList(*output), err
+	return enriched(s.Raw().GetVehicleData(ctx))
 }
 
 func getEndpoint(testEndpoint bool) url.URL {
@@ -224,49 +244,214 @@ func getEndpoint(testEndpoint bool) url.URL {
 	return *u
 }
 
+// refreshToken replaces the token if it's still oldToken. If several goroutines call this function at the
+// same time, only one refresh is done and all of them receive its result. The refresh is not cancelled
+// if the caller's context is cancelled, so the other callers can still use it.
 func (s *raildataClient) refreshToken(ctx context.Context, oldToken string) error {
-	if s.credentials == nil {
-		return rderrors.MissingCredentialsError
-	}
-
 	s.tokenMutex.Lock()
-	defer s.tokenMutex.Unlock()
 	if s.token != oldToken {
+		s.tokenMutex.Unlock()
 		return nil
 	}
+	refresh := s.tokenRefresh
+	if refresh == nil {
+		refresh = &tokenRefresh{done: make(chan struct{})}
+		s.tokenRefresh = refresh
+		go s.doRefreshToken(context.WithoutCancel(ctx), oldToken, refresh)
+	}
+	s.tokenMutex.Unlock()
 
-	input := &api.GetTokenRequest{
-		Username: s.credentials.username,
-		Password: s.credentials.password,
+	select {
+	case <-refresh.done:
+		return refresh.err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	output, err := api.GetToken.Request(ctx, s.client, s.apiBase, input)
+}
+
+type tokenRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+func (s *raildataClient) doRefreshToken(ctx context.Context, oldToken string, refresh *tokenRefresh) {
+	var span Span
+	if s.tracer != nil {
+		ctx, span = s.tracer.Start(ctx, "raildata.refreshToken")
+	}
+	token, created, err := s.obtainToken(ctx, oldToken)
+	if span != nil {
+		span.SetAttributes(TraceAttribute{"raildata.token_created", created}, TraceAttribute{TraceAttrErrorClass, rderrors.Classify(err)})
+		span.End(err)
+	}
+
+	switch {
+	case err != nil:
+		s.logger.Warn("token refresh failed", "status", rderrors.Classify(err), "error", err.Error())
+	case created:
+		s.logger.Info("created new token")
+	default:
+		s.logger.Info("loaded new token from token store")
+	}
+
+	s.tokenMutex.Lock()
+	if err == nil {
+		s.token = token
+		s.tokenReplaced(created, time.Now())
+	}
+	s.tokenRefresh = nil
+	s.tokenMutex.Unlock()
+
+	refresh.err = err
+	close(refresh.done)
+	if created {
+		for _, listener := range s.tokenUpdateListeners {
+			go s.callListener(listener, token, oldToken)
+		}
+	}
+}
+
+// obtainToken returns a token to replace oldToken, either from the token store or by creating a new one.
+// It returns whether a new token was created.
+func (s *raildataClient) obtainToken(ctx context.Context, oldToken string) (string, bool, error) {
+	storedToken := oldToken
+	if s.tokenStore != nil {
+		token, err := s.tokenStore.Load()
+		if err != nil {
+			return "", false, err
+		}
+		if len(token) > 0 && token != oldToken {
+			return token, false, nil
+		}
+		storedToken = token
+	}
+
+	if s.credentials == nil {
+		return "", false, rderrors.MissingCredentialsError
+	}
+	// The interceptors don't get to see the credentials.
+	output, _, err := intercept(api.GetToken, s, ctx, &api.GetTokenRequest{}, func(ctx context.Context, req *api.GetTokenRequest) (*api.GetTokenResponse, []byte, error) {
+		req.Username = s.credentials.username
+		req.Password = s.credentials.password
+		raw, err := send(api.GetToken, s, ctx, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		output, err := api.GetToken.Decode(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		return output, raw, nil
+	})
 	if err != nil {
-		return err
+		return "", false, err
 	}
 
 	if output.Authenticated != "True" {
-		return rderrors.BadCredentialsError
+		return "", false, rderrors.BadCredentialsError
 	}
-	s.token = output.UserToken
-	for _, listener := range s.tokenUpdateListeners {
-		go listener(output.UserToken, oldToken)
+	if s.tokenStore != nil {
+		if _, err := s.tokenStore.CompareAndSwap(storedToken, output.UserToken); err != nil {
+			s.logger.Error("could not save token to token store", "error", err.Error())
+		}
 	}
-	return nil
+	return output.UserToken, true, nil
 }
 
-func request[I any, O any](method api.MethodDefinition[I, O], s *raildataClient, ctx context.Context, input *I) (*O, error) {
-	token := s.GetToken()
-	method.SetToken(input, token)
-	out, err := method.Request(ctx, s.client, s.apiBase, input)
-	if !errors.Is(err, rderrors.InvalidTokenError) {
-		return out, err
+func request[I any, O any](method api.MethodDefinition[I, O], s *raildataClient, ctx context.Context, input *I) (*O, []byte, ResponseInfo, error) {
+	ctx, span := s.startSpan(ctx, method.Name, input)
+	out, raw, info, err := cachedInvoke(method, s, ctx, input)
+	span.end(info.Cached, err)
+	return out, raw, info, err
+}
+
+func cachedInvoke[I any, O any](method api.MethodDefinition[I, O], s *raildataClient, ctx context.Context, input *I) (*O, []byte, ResponseInfo, error) {
+	ttl := s.cacheTTL(method.Name)
+	if s.cache == nil {
+		ttl = 0
+	}
+	stale := s.staleCache()
+	if method.Name == api.IsValidToken.Name {
+		// An old response can't tell whether the current token is valid.
+		stale = nil
+	}
+	if ttl <= 0 && stale == nil {
+		out, raw, err := coalesce(method, s, ctx, input)
+		return out, raw, ResponseInfo{}, err
 	}
 
-	err = s.refreshToken(ctx, token)
+	key, err := cacheKey(method, input)
 	if err != nil {
-		return nil, err
+		return nil, nil, ResponseInfo{}, err
+	}
+	now := time.Now()
+	if ttl > 0 {
+		if entry, found := s.cache.Get(key); found && now.Before(entry.Expires) {
+			if out, err := method.Decode(entry.Data); err == nil {
+				return out, entry.Data, ResponseInfo{Cached: true, Age: now.Sub(entry.Created)}, nil
+			}
+		}
+	}
+
+	out, raw, err := coalesce(method, s, ctx, input)
+	if err != nil {
+		if stale != nil && isTransient(ctx, err) {
+			now = time.Now()
+			if entry, found := stale.Get(key); found && now.Sub(entry.Created) <= s.maxStaleness {
+				if out, decodeErr := method.Decode(entry.Data); decodeErr == nil {
+					age := now.Sub(entry.Created)
+					s.logger.Warn("serving stale response", "method", method.Name, "age", age, "error", err.Error())
+					return out, entry.Data, ResponseInfo{Cached: true, Stale: true, Age: age}, nil
+				}
+			}
+		}
+		return nil, nil, ResponseInfo{}, err
 	}
-	token = s.GetToken()
+	now = time.Now()
+	// Entries that are only kept for the stale fallback expire immediately, so they are never served as fresh.
+	entry := &CacheEntry{Data: raw, Created: now, Expires: now.Add(max(ttl, 0))}
+	if ttl > 0 {
+		s.cache.Put(key, entry)
+	} else {
+		stale.Put(key, entry)
+	}
+	return out, raw, ResponseInfo{}, nil
+}
+
+func fetch[I any, O any](method api.MethodDefinition[I, O], s *raildataClient, ctx context.Context, input *I) (*O, []byte, error) {
+	s.inflight.Add(1)
+	defer s.inflight.Add(-1)
+
+	span := spanFromContext(ctx)
+	call := func() ([]byte, error) {
+		raw, err := send(method, s, ctx, input)
+		span.responded(err)
+		return raw, err
+	}
+	token := s.GetToken()
 	method.SetToken(input, token)
-	return method.Request(ctx, s.client, s.apiBase, input)
+	raw, err := s.retry(ctx, method.Name, call)
+	if errors.Is(err, rderrors.InvalidTokenError) {
+		s.tokenRejected(token, time.Now())
+		span.refreshingToken()
+		err = s.refreshToken(ctx, token)
+		if err != nil {
+			return nil, nil, err
+		}
+		token = s.GetToken()
+		method.SetToken(input, token)
+		raw, err = s.retry(ctx, method.Name, call)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	s.tokenValidated(token, time.Now())
+	if s.schemaMonitor != nil {
+		s.schemaMonitor.observe(method.Name, raw, reflect.TypeFor[O]())
+	}
+	out, err := method.Decode(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, raw, nil
 }
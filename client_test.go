@@ -77,6 +77,65 @@ func TestOtherError(t *testing.T) {
 	assert.Equal(t, "some error message", rderr.Error())
 }
 
+func TestHttpStatusError(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getStationList").sendResponse(502, "<html>Bad Gateway</html>"))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	var herr *errors.HttpStatusError
+	require.ErrorAs(t, err, &herr)
+	assert.Equal(t, "getStationList", herr.Method)
+	assert.Equal(t, 502, herr.StatusCode)
+	assert.True(t, herr.Temporary())
+}
+
+func TestHttpStatusErrorWithMessage(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getStationList").sendError("some error message"))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	var herr *errors.HttpStatusError
+	require.ErrorAs(t, err, &herr)
+	assert.Equal(t, "getStationList", herr.Method)
+	assert.Equal(t, 500, herr.StatusCode)
+	assert.False(t, herr.Temporary())
+	var rderr *errors.RailDataError
+	require.ErrorAs(t, err, &rderr)
+	assert.Equal(t, "some error message", rderr.Error())
+	assert.Equal(t, "api_error", errors.Classify(err))
+}
+
+func TestDecodeError(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getStationList").sendJson(`{"not": "a list"}`))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	var derr *errors.DecodeError
+	require.ErrorAs(t, err, &derr)
+	assert.Equal(t, "getStationList", derr.Method)
+	assert.Equal(t, `{"not": "a list"}`, derr.BodyExcerpt)
+}
+
+func TestTransportError(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getStationList").sendJson(`[]`))
+	option := withServerUrl(t, server)
+	server.Close()
+
+	client, err := raildata.NewClient(option, raildata.WithToken(testToken))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	var terr *errors.TransportError
+	require.ErrorAs(t, err, &terr)
+	assert.Equal(t, "getStationList", terr.Method)
+}
+
 func TestRenewTokenWhenRequired(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		require.NoError(t, req.ParseMultipartForm(5000000))
@@ -160,6 +219,12 @@ func TestRenewTokenPropagatesErrorOnLimitExceeded(t *testing.T) {
 	var rderr *errors.RailDataError
 	assert.ErrorAs(t, err, &rderr)
 	assert.Equal(t, "Daily usage limit:10. Your current daily usage: 11", rderr.Error())
+	var qerr *errors.QuotaExceededError
+	require.ErrorAs(t, err, &qerr)
+	assert.Equal(t, "getToken", qerr.Method)
+	assert.Equal(t, 10, qerr.Limit)
+	assert.Equal(t, 11, qerr.Usage)
+	assert.False(t, qerr.ResetTime.IsZero())
 }
 
 func TestGetStationList(t *testing.T) {
@@ -740,6 +805,11 @@ func TestGetTrainSchedule19Rec(t *testing.T) {
 						CapacityPercent: 1,
 						CapacityColor:   color(t, "#0B6623"),
 						PassengerCount:  6,
+
+						PreviousCapacityPercent: ptr(1),
+						PreviousCapacityColor:   ptr(color(t, "#0B6623")),
+						PreviousPassengerCount:  ptr(6),
+						Trend:                   &raildata.CapacityTrend{Direction: raildata.TrendSteady, PassengerDelta: 0, PercentDelta: ptr(0)},
 						Sections: []raildata.TrainSection{
 							{
 								Position:        raildata.SectionPositionBack,
@@ -889,7 +959,9 @@ func TestGetTrainStopListForInvalidTrain(t *testing.T) {
 
 	req := &raildata.GetTrainStopListRequest{TrainId: "3737"}
 	actual, err := client.GetTrainStopList(context.Background(), req)
-	assert.NoError(t, err)
+	var notFound *errors.TrainNotFoundError
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, "3737", notFound.TrainId)
 	assert.Nil(t, actual)
 }
 
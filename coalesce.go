@@ -0,0 +1,77 @@
+package raildata
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jtarrio/raildata/api"
+)
+
+// WithRequestCoalescing makes the client deduplicate identical calls that are in progress at the same time.
+//
+// When a call is made with the same method and arguments as another call that hasn't finished yet,
+// the client doesn't make a new request to the RailData API; instead, it waits for the call in progress
+// and returns its result. The shared request is not cancelled if a caller's context is cancelled,
+// so the other callers can still use its result.
+func WithRequestCoalescing() Option {
+	return func(s *raildataClient) {
+		s.coalescer = &coalescer{calls: map[string]*sharedCall{}}
+	}
+}
+
+type coalescer struct {
+	mutex sync.Mutex
+	calls map[string]*sharedCall
+}
+
+type sharedCall struct {
+	done chan struct{}
+	span *callSpan
+	out  any
+	raw  []byte
+	err  error
+}
+
+// coalesce calls invoke, or waits for an identical call already in progress.
+func coalesce[I any, O any](method api.MethodDefinition[I, O], s *raildataClient, ctx context.Context, input *I) (*O, []byte, error) {
+	if s.coalescer == nil {
+		return invoke(method, s, ctx, input)
+	}
+	key, err := cacheKey(method, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := s.coalescer
+	c.mutex.Lock()
+	call, found := c.calls[key]
+	if !found {
+		call = &sharedCall{done: make(chan struct{}), span: &callSpan{}}
+		c.calls[key] = call
+		req := *input
+		// The shared call outlives the caller that started it, so it collects its counters in its own callSpan,
+		// which is copied to the span of every caller that receives its result.
+		sharedCtx := context.WithValue(context.WithoutCancel(ctx), callSpanKey{}, call.span)
+		go func() {
+			out, raw, err := invoke(method, s, sharedCtx, &req)
+			call.out, call.raw, call.err = out, raw, err
+
+			c.mutex.Lock()
+			delete(c.calls, key)
+			c.mutex.Unlock()
+			close(call.done)
+		}()
+	}
+	c.mutex.Unlock()
+
+	select {
+	case <-call.done:
+		spanFromContext(ctx).absorb(call.span)
+		if call.err != nil {
+			return nil, nil, call.err
+		}
+		return call.out.(*O), call.raw, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
@@ -0,0 +1,126 @@
+package raildata_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestCoalescing(t *testing.T) {
+	var count atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		count.Add(1)
+		<-release
+		require.NoError(t, req.ParseMultipartForm(5000000))
+		station := req.Form.Get("station")
+		expectRequest(t, "getTrainSchedule", "station", station).sendJson(`{"STATION_2CHAR": "`+station+`", "ITEMS": []}`).ServeHTTP(rw, req)
+	}))
+	defer server.Close()
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithRequestCoalescing())
+	require.NoError(t, err)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	responses := make([]*raildata.GetTrainScheduleResponse, callers)
+	errs := make([]error, callers)
+	for i := range callers {
+		station := raildata.StationCode("NY")
+		if i == 0 {
+			station = "NP"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i], errs[i] = client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: station})
+		}()
+	}
+
+	// A caller that gives up doesn't cancel the shared request.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := client.GetTrainSchedule(ctx, &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+		cancelled <- err
+	}()
+	require.Eventually(t, func() bool { return count.Load() == 2 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+
+	// Give the remaining callers time to join the shared request.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(2), count.Load())
+	for i := range callers {
+		require.NoError(t, errs[i])
+	}
+	assert.Equal(t, raildata.StationCode("NP"), responses[0].Station.Code)
+	for i := 1; i < callers; i++ {
+		assert.Equal(t, raildata.StationCode("NY"), responses[i].Station.Code)
+		assert.Equal(t, responses[1], responses[i])
+	}
+
+	// Once the shared call finishes, new calls make new requests.
+	_, err = client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), count.Load())
+}
+
+func TestRequestCoalescingWithTracer(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		expectRequest(t, "getTrainSchedule", "station", "NY").sendJson(`{"STATION_2CHAR": "NY", "ITEMS": []}`).ServeHTTP(rw, req)
+	}))
+	defer server.Close()
+
+	tracer := &fakeTracer{}
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithRequestCoalescing(), raildata.WithTracer(tracer))
+	require.NoError(t, err)
+
+	// The caller that starts the shared request gives up before it finishes.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := client.GetTrainSchedule(ctx, &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+		cancelled <- err
+	}()
+	<-started
+	done := make(chan error)
+	go func() {
+		_, err := client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+		done <- err
+	}()
+	require.Eventually(t, func() bool {
+		tracer.mutex.Lock()
+		defer tracer.mutex.Unlock()
+		return len(tracer.spans) == 2
+	}, time.Second, time.Millisecond)
+	// Give the second caller time to join the shared request.
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+	close(release)
+	require.NoError(t, <-done)
+
+	require.Len(t, tracer.spans, 2)
+	first, second := tracer.spans[0], tracer.spans[1]
+	assert.True(t, first.ended)
+	assert.ErrorIs(t, first.err, context.Canceled)
+	assert.NotContains(t, first.attributes, raildata.TraceAttrHttpStatus)
+	assert.True(t, second.ended)
+	assert.NoError(t, second.err)
+	assert.Equal(t, 200, second.attributes[raildata.TraceAttrHttpStatus])
+}
@@ -0,0 +1,354 @@
+// Package departures builds departure boards for NJ Transit rail stations.
+//
+// A departure board combines a station's timetable from GetStationSchedule, the realtime track, status and delay
+// information from GetTrainSchedule, and the alerts from GetStationMsg, so that a station screen only needs to make
+// a single call.
+//
+// GetStationSchedule is one of the [raildata.RateLimitedMethods], so the [Aggregator] keeps each station's timetable
+// in memory and only retrieves it again when it is older than the schedule TTL. If the timetable can't be retrieved,
+// the board is built from the realtime information alone, and the aggregator waits for the retry interval
+// before trying again.
+//
+// The timetable only contains NJ Transit trains, and it is requested the same way as in the
+// [github.com/jtarrio/raildata/planner] package, so a client with a cache can share it between both.
+//
+// Example:
+//
+//	a := departures.New(client)
+//	board, err := a.DepartureBoard(ctx, "NY")
+//	if err != nil { return err }
+//	for _, departure := range board.Departures {
+//	    fmt.Println(departure.ScheduledTime, departure.TrainId, departure.Destination)
+//	}
+package departures
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jtarrio/raildata"
+)
+
+// DefaultScheduleTTL contains the default time a station's timetable is kept before retrieving it again.
+const DefaultScheduleTTL = 24 * time.Hour
+
+// DefaultRetryInterval contains the default time to wait after failing to retrieve a station's timetable
+// before trying again.
+const DefaultRetryInterval = time.Hour
+
+// DefaultHorizon contains the default length of time after the current time covered by a departure board.
+const DefaultHorizon = 2 * time.Hour
+
+// maxIdGap contains the longest interval between the scheduled and realtime departure times of a train for them
+// to be considered the same run. Train numbers are reused every day.
+const maxIdGap = 6 * time.Hour
+
+// Board contains the merged departure information for a station.
+type Board struct {
+	// Station contains the station this board belongs to.
+	Station raildata.Station
+	// Departures contains the departures from this station, sorted by scheduled departure time.
+	Departures []Departure
+	// Messages contains the alerts for this station, most recent first.
+	Messages []raildata.StationMsg
+	// HasMessages contains whether the station's alerts from GetStationMsg were available to build this board.
+	// If false, Messages only contains the alerts that come with the realtime information.
+	HasMessages bool
+	// HasSchedule contains whether the station's timetable was available to build this board.
+	// If false, the board only contains trains with realtime information.
+	HasSchedule bool
+	// Updated contains the date/time this board was built.
+	Updated time.Time
+}
+
+// Departure contains the information about a train departing from a station.
+type Departure struct {
+	// TrainId contains the train's number.
+	TrainId string
+	// Line contains the line this train runs on.
+	Line raildata.Line
+	// Destination contains the destination name.
+	Destination string
+	// ScheduledTime contains the scheduled departure date/time for the train.
+	ScheduledTime time.Time
+	// ExpectedTime contains the expected departure date/time, including the delay if known.
+	ExpectedTime time.Time
+	// Delay contains the train's current delay, if known.
+	Delay *time.Duration
+	// Track contains the name of the track this train will leave from, if known.
+	Track *string
+	// Status contains the train's current status, if known.
+	Status *string
+	// Cancelled indicates, if true, that the train has been cancelled or won't stop at this station.
+	Cancelled bool
+	// Scheduled indicates, if true, that this train appears in the station's timetable.
+	Scheduled bool
+	// Realtime indicates, if true, that realtime information is available for this train.
+	// Trains that are in the timetable but too far in the future don't have realtime information yet.
+	Realtime bool
+	// InlineMessage contains an in-line message for the train at the station.
+	InlineMessage *string
+}
+
+// Option is the type of the options that can be passed to [New].
+type Option func(*Aggregator)
+
+// WithScheduleTTL sets how long a station's timetable is kept before retrieving it again.
+// The default is [DefaultScheduleTTL].
+func WithScheduleTTL(ttl time.Duration) Option {
+	return func(a *Aggregator) {
+		a.scheduleTTL = ttl
+	}
+}
+
+// WithRetryInterval sets how long to wait after failing to retrieve a station's timetable before trying again.
+// The default is [DefaultRetryInterval].
+func WithRetryInterval(interval time.Duration) Option {
+	return func(a *Aggregator) {
+		a.retryInterval = interval
+	}
+}
+
+// WithHorizon sets the length of time after the current time covered by a departure board.
+// The default is [DefaultHorizon].
+func WithHorizon(horizon time.Duration) Option {
+	return func(a *Aggregator) {
+		a.horizon = horizon
+	}
+}
+
+// WithClock sets the function that the aggregator uses to get the current time.
+func WithClock(now func() time.Time) Option {
+	return func(a *Aggregator) {
+		a.now = now
+	}
+}
+
+// Aggregator builds departure boards using a RailData client.
+//
+// An Aggregator is safe for concurrent use.
+type Aggregator struct {
+	client        raildata.Client
+	scheduleTTL   time.Duration
+	retryInterval time.Duration
+	horizon       time.Duration
+	now           func() time.Time
+
+	mutex     sync.Mutex
+	schedules map[raildata.StationCode]*timetable
+}
+
+// timetable contains a station's timetable and the state of its retrieval.
+type timetable struct {
+	entries []raildata.ScheduleEntry
+	// fetched contains the time the timetable was retrieved, or zero if it hasn't been retrieved yet.
+	fetched time.Time
+	// failed contains the time of the last failed attempt to retrieve the timetable.
+	failed time.Time
+	// loading is non-nil while the timetable is being retrieved, and is closed when the retrieval finishes.
+	loading chan struct{}
+}
+
+// New creates an [Aggregator] that uses the given client.
+func New(client raildata.Client, options ...Option) *Aggregator {
+	a := &Aggregator{
+		client:        client,
+		scheduleTTL:   DefaultScheduleTTL,
+		retryInterval: DefaultRetryInterval,
+		horizon:       DefaultHorizon,
+		now:           time.Now,
+		schedules:     map[raildata.StationCode]*timetable{},
+	}
+	for _, opt := range options {
+		opt(a)
+	}
+	return a
+}
+
+// DepartureBoard returns the departure board for the given station.
+//
+// The departures in the timetable are joined with the realtime information by train id. Trains that are in the
+// timetable but don't have realtime information yet are included, as well as trains that only appear in the
+// realtime information. Departures that are further in the future than the horizon are omitted.
+//
+// If the station's alerts or timetable can't be retrieved, the board is built without them, and its
+// HasMessages or HasSchedule field is false. An error is only returned if the realtime information is not available.
+func (a *Aggregator) DepartureBoard(ctx context.Context, station raildata.StationCode) (*Board, error) {
+	realtime, err := a.client.GetTrainSchedule(ctx, &raildata.GetTrainScheduleRequest{StationCode: station})
+	if err != nil {
+		return nil, err
+	}
+	var stationMsgs []raildata.StationMsg
+	msgs, err := a.client.GetStationMsg(ctx, &raildata.GetStationMsgRequest{StationCode: &station})
+	hasMessages := err == nil
+	if hasMessages {
+		stationMsgs = msgs.Messages
+	} else if ctx.Err() != nil {
+		return nil, err
+	}
+	schedule, hasSchedule := a.schedule(ctx, station)
+
+	now := a.now()
+	board := &Board{
+		Station:     realtime.Station,
+		Messages:    mergeMessages(stationMsgs, realtime.Messages),
+		HasMessages: hasMessages,
+		HasSchedule: hasSchedule,
+		Updated:     now,
+	}
+	used := make([]bool, len(schedule))
+	for i := range realtime.Entries {
+		entry := &realtime.Entries[i]
+		departure := Departure{
+			TrainId:       entry.TrainId,
+			Line:          entry.Line,
+			Destination:   entry.Destination,
+			ScheduledTime: entry.DepartureTime,
+			Delay:         entry.Delay,
+			Track:         entry.Track,
+			Status:        entry.Status,
+			Cancelled:     isCancelled(entry, station),
+			Realtime:      true,
+			InlineMessage: entry.InlineMessage,
+		}
+		if j := findScheduled(schedule, used, entry); j >= 0 {
+			used[j] = true
+			departure.Scheduled = true
+			departure.ScheduledTime = schedule[j].DepartureTime
+		}
+		departure.ExpectedTime = departure.ScheduledTime
+		if entry.Delay != nil {
+			departure.ExpectedTime = departure.ExpectedTime.Add(*entry.Delay)
+		}
+		board.Departures = append(board.Departures, departure)
+	}
+	for j := range schedule {
+		entry := &schedule[j]
+		if used[j] || entry.DepartureTime.Before(now) {
+			continue
+		}
+		board.Departures = append(board.Departures, Departure{
+			TrainId:       entry.TrainId,
+			Line:          entry.Line,
+			Destination:   entry.Destination,
+			ScheduledTime: entry.DepartureTime,
+			ExpectedTime:  entry.DepartureTime,
+			Scheduled:     true,
+		})
+	}
+	board.Departures = slices.DeleteFunc(board.Departures, func(d Departure) bool {
+		return d.ScheduledTime.After(now.Add(a.horizon))
+	})
+	slices.SortStableFunc(board.Departures, func(a, b Departure) int {
+		return cmp.Or(a.ScheduledTime.Compare(b.ScheduledTime), strings.Compare(a.TrainId, b.TrainId))
+	})
+	return board, nil
+}
+
+// schedule returns the departures in the station's timetable, retrieving it if it's not known or too old.
+// If it can't be retrieved, the previous timetable is returned, if any.
+//
+// Only one retrieval per station is made at a time; other callers wait for it to finish. After a failed attempt,
+// the timetable is not retrieved again until the retry interval has elapsed.
+func (a *Aggregator) schedule(ctx context.Context, station raildata.StationCode) ([]raildata.ScheduleEntry, bool) {
+	a.mutex.Lock()
+	t, found := a.schedules[station]
+	if !found {
+		t = &timetable{}
+		a.schedules[station] = t
+	}
+	for t.loading != nil {
+		loading := t.loading
+		a.mutex.Unlock()
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			a.mutex.Lock()
+			defer a.mutex.Unlock()
+			return t.entries, !t.fetched.IsZero()
+		}
+		a.mutex.Lock()
+	}
+	now := a.now()
+	if (!t.fetched.IsZero() && now.Sub(t.fetched) < a.scheduleTTL) || (!t.failed.IsZero() && now.Sub(t.failed) < a.retryInterval) {
+		defer a.mutex.Unlock()
+		return t.entries, !t.fetched.IsZero()
+	}
+	loading := make(chan struct{})
+	t.loading = loading
+	a.mutex.Unlock()
+
+	response, err := a.client.RateLimitedMethods().GetStationSchedule(ctx, &raildata.GetStationScheduleRequest{StationCode: station, NjtOnly: true})
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	t.loading = nil
+	close(loading)
+	if err != nil {
+		// A cancelled caller doesn't mean that the timetable is unavailable.
+		if ctx.Err() == nil {
+			t.failed = now
+		}
+		return t.entries, !t.fetched.IsZero()
+	}
+	var entries []raildata.ScheduleEntry
+	for _, schedule := range response.Entries {
+		for _, entry := range schedule.Entries {
+			// Trains that end at this station don't depart from it.
+			if entry.StationPosition.Code == "2" || entry.DropoffOnly {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	t.entries, t.fetched, t.failed = entries, now, time.Time{}
+	return entries, true
+}
+
+// findScheduled returns the index of the unused timetable entry for the same train as the realtime entry
+// with the closest departure time, or -1 if there isn't one.
+func findScheduled(schedule []raildata.ScheduleEntry, used []bool, entry *raildata.TrainScheduleEntry) int {
+	best := -1
+	var bestGap time.Duration
+	for j := range schedule {
+		if used[j] || schedule[j].TrainId != entry.TrainId {
+			continue
+		}
+		gap := schedule[j].DepartureTime.Sub(entry.DepartureTime).Abs()
+		if gap > maxIdGap {
+			continue
+		}
+		if best < 0 || gap < bestGap {
+			best, bestGap = j, gap
+		}
+	}
+	return best
+}
+
+// isCancelled returns whether the train's status or its stop at the given station say that it's been cancelled.
+func isCancelled(entry *raildata.TrainScheduleEntry, station raildata.StationCode) bool {
+	if entry.IsCancelled() {
+		return true
+	}
+	for i := range entry.Stops {
+		if entry.Stops[i].Station.Code == station && entry.Stops[i].IsCancelled() {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeMessages returns the messages from both lists without duplicates, most recent first.
+func mergeMessages(a []raildata.StationMsg, b []raildata.StationMsg) []raildata.StationMsg {
+	out := slices.Concat(a, b)
+	slices.SortStableFunc(out, func(a, b raildata.StationMsg) int {
+		return cmp.Or(b.PubDate.Compare(a.PubDate), strings.Compare(a.Text, b.Text))
+	})
+	return slices.CompactFunc(out, func(a, b raildata.StationMsg) bool {
+		return a.Text == b.Text && a.PubDate.Equal(b.PubDate)
+	})
+}
@@ -0,0 +1,213 @@
+package departures_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/departures"
+	"github.com/jtarrio/raildata/planner"
+	"github.com/jtarrio/raildata/raildatatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTrain returns a train that stops at Newark Penn (NP) at the given time.
+func newTrain(id string, minutes int) raildatatest.Train {
+	return raildatatest.Train{
+		TrainId:   id,
+		Line:      raildatatest.Line("NE"),
+		Direction: raildata.DirectionWestbound,
+		Status:    "On Time",
+		Stops: []raildatatest.Stop{
+			{Station: raildatatest.Station("NY"), Time: raildatatest.At(minutes - 20), Dwell: time.Minute, Departed: minutes < 20},
+			{Station: raildatatest.Station("NP"), Time: raildatatest.At(minutes), Dwell: time.Minute, Track: "3", Departed: minutes < 0},
+			{Station: raildatatest.Station("TR"), Time: raildatatest.At(minutes + 50)},
+		},
+	}
+}
+
+func newAggregator(t *testing.T, server *raildatatest.Server, options ...departures.Option) *departures.Aggregator {
+	return departures.New(server.NewClient(t), append([]departures.Option{departures.WithClock(func() time.Time { return raildatatest.BaseTime })}, options...)...)
+}
+
+func trainIds(board *departures.Board) []string {
+	var out []string
+	for _, departure := range board.Departures {
+		out = append(out, departure.TrainId)
+	}
+	return out
+}
+
+func TestDepartureBoard(t *testing.T) {
+	server := raildatatest.NewServer(raildatatest.WithClock(func() time.Time { return raildatatest.BaseTime }))
+	defer server.Close()
+	server.AddTrain(newTrain("100", -30))
+	delayed := newTrain("102", 5)
+	delayed.Delay = 3 * time.Minute
+	server.AddTrain(delayed)
+	cancelled := newTrain("104", 20)
+	cancelled.Status = "CANCELLED"
+	server.AddTrain(cancelled)
+	server.AddTrain(newTrain("106", 60))
+	server.AddTrain(newTrain("108", 200))
+	server.AddMessage(raildatatest.Message{Text: "Elevator out of service", PubDate: raildatatest.At(-60), Stations: []raildata.Station{raildatatest.Station("NP")}})
+	server.AddMessage(raildatatest.Message{Text: "Delays on NEC", PubDate: raildatatest.At(-10), Stations: []raildata.Station{raildatatest.Station("NP")}})
+	a := newAggregator(t, server)
+
+	board, err := a.DepartureBoard(context.Background(), "NP")
+	require.NoError(t, err)
+	assert.Equal(t, raildata.StationCode("NP"), board.Station.Code)
+	assert.True(t, board.HasMessages)
+	assert.True(t, board.HasSchedule)
+	assert.Equal(t, raildatatest.BaseTime, board.Updated)
+	// Train 100 has already left, and train 108 is beyond the horizon.
+	assert.Equal(t, []string{"102", "104", "106"}, trainIds(board))
+
+	departure := board.Departures[0]
+	assert.True(t, departure.Scheduled)
+	assert.True(t, departure.Realtime)
+	assert.False(t, departure.Cancelled)
+	assert.WithinDuration(t, raildatatest.At(6), departure.ScheduledTime, 0)
+	assert.WithinDuration(t, raildatatest.At(9), departure.ExpectedTime, 0)
+	require.NotNil(t, departure.Delay)
+	assert.Equal(t, 3*time.Minute, *departure.Delay)
+	require.NotNil(t, departure.Track)
+	assert.Equal(t, "3", *departure.Track)
+	assert.Equal(t, raildata.LineCode("NE"), departure.Line.Code)
+	assert.Equal(t, "Trenton", departure.Destination)
+
+	assert.True(t, board.Departures[1].Cancelled)
+	assert.False(t, board.Departures[2].Cancelled)
+
+	require.Len(t, board.Messages, 2)
+	assert.Equal(t, "Delays on NEC", board.Messages[0].Text)
+	assert.Equal(t, "Elevator out of service", board.Messages[1].Text)
+}
+
+func TestDepartureBoardMergesScheduleAndRealtime(t *testing.T) {
+	server := raildatatest.NewServer(raildatatest.WithClock(func() time.Time { return raildatatest.BaseTime }))
+	defer server.Close()
+	server.AddTrain(newTrain("102", 5))
+	server.AddTrain(newTrain("104", 20))
+	a := newAggregator(t, server)
+
+	_, err := a.DepartureBoard(context.Background(), "NP")
+	require.NoError(t, err)
+
+	// The timetable is kept, so the changes are only seen in the realtime information.
+	server.RemoveTrain("104")
+	server.AddTrain(newTrain("106", 30))
+	board, err := a.DepartureBoard(context.Background(), "NP")
+	require.NoError(t, err)
+	assert.Equal(t, 1, server.RequestCount("getStationSchedule"))
+	assert.Equal(t, []string{"102", "104", "106"}, trainIds(board))
+
+	assert.True(t, board.Departures[0].Scheduled)
+	assert.True(t, board.Departures[0].Realtime)
+	assert.True(t, board.Departures[1].Scheduled)
+	assert.False(t, board.Departures[1].Realtime)
+	assert.Nil(t, board.Departures[1].Track)
+	assert.WithinDuration(t, raildatatest.At(21), board.Departures[1].ExpectedTime, 0)
+	assert.False(t, board.Departures[2].Scheduled)
+	assert.True(t, board.Departures[2].Realtime)
+}
+
+func TestDepartureBoardWithoutMessages(t *testing.T) {
+	server := raildatatest.NewServer(raildatatest.WithClock(func() time.Time { return raildatatest.BaseTime }))
+	defer server.Close()
+	server.AddTrain(newTrain("102", 5))
+	server.AddMessage(raildatatest.Message{Text: "Elevator out of service", PubDate: raildatatest.At(-60), Stations: []raildata.Station{raildatatest.Station("NP")}})
+	server.InjectFault("getStationMSG", raildatatest.Fault{StatusCode: 500, Count: 1})
+	a := newAggregator(t, server)
+
+	board, err := a.DepartureBoard(context.Background(), "NP")
+	require.NoError(t, err)
+	assert.False(t, board.HasMessages)
+	// The alerts that come with the realtime information are still there.
+	assert.Len(t, board.Messages, 1)
+	assert.True(t, board.HasSchedule)
+	assert.Equal(t, []string{"102"}, trainIds(board))
+
+	board, err = a.DepartureBoard(context.Background(), "NP")
+	require.NoError(t, err)
+	assert.True(t, board.HasMessages)
+	assert.Equal(t, 2, server.RequestCount("getStationMSG"))
+}
+
+func TestDepartureBoardWithoutSchedule(t *testing.T) {
+	server := raildatatest.NewServer(raildatatest.WithClock(func() time.Time { return raildatatest.BaseTime }))
+	defer server.Close()
+	server.AddTrain(newTrain("102", 5))
+	server.InjectFault("getStationSchedule", raildatatest.Fault{StatusCode: 500, Count: 1})
+	clock := raildatatest.BaseTime
+	a := newAggregator(t, server, departures.WithClock(func() time.Time { return clock }), departures.WithRetryInterval(30*time.Minute))
+
+	board, err := a.DepartureBoard(context.Background(), "NP")
+	require.NoError(t, err)
+	assert.False(t, board.HasSchedule)
+	assert.Equal(t, []string{"102"}, trainIds(board))
+	assert.False(t, board.Departures[0].Scheduled)
+	assert.True(t, board.Departures[0].Realtime)
+
+	// The timetable is not retrieved again until the retry interval has elapsed.
+	clock = raildatatest.At(29)
+	board, err = a.DepartureBoard(context.Background(), "NP")
+	require.NoError(t, err)
+	assert.False(t, board.HasSchedule)
+	assert.Equal(t, 1, server.RequestCount("getStationSchedule"))
+
+	clock = raildatatest.At(30)
+	board, err = a.DepartureBoard(context.Background(), "NP")
+	require.NoError(t, err)
+	assert.True(t, board.HasSchedule)
+	assert.True(t, board.Departures[0].Scheduled)
+	assert.Equal(t, 2, server.RequestCount("getStationSchedule"))
+}
+
+func TestDepartureBoardRetrievesScheduleOnce(t *testing.T) {
+	server := raildatatest.NewServer(raildatatest.WithClock(func() time.Time { return raildatatest.BaseTime }))
+	defer server.Close()
+	server.AddTrain(newTrain("102", 5))
+	a := newAggregator(t, server)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			board, err := a.DepartureBoard(context.Background(), "NP")
+			if assert.NoError(t, err) {
+				assert.True(t, board.HasSchedule)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, server.RequestCount("getStationSchedule"))
+}
+
+func TestDepartureBoardFailsWithoutRealtime(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	server.InjectFault("getTrainSchedule", raildatatest.Fault{StatusCode: 500, Count: 1})
+	a := newAggregator(t, server)
+
+	_, err := a.DepartureBoard(context.Background(), "NP")
+	assert.Error(t, err)
+}
+
+func TestDepartureBoardSharesScheduleWithPlanner(t *testing.T) {
+	server := raildatatest.NewServer(raildatatest.WithClock(func() time.Time { return raildatatest.BaseTime }))
+	defer server.Close()
+	server.AddTrain(newTrain("102", 5))
+	client := server.NewClient(t, raildata.WithCache(raildata.NewMemoryCache(10)))
+
+	require.NoError(t, planner.New(client).Load(context.Background(), "NP"))
+	a := departures.New(client, departures.WithClock(func() time.Time { return raildatatest.BaseTime }))
+	board, err := a.DepartureBoard(context.Background(), "NP")
+	require.NoError(t, err)
+	assert.True(t, board.HasSchedule)
+	assert.Equal(t, 1, server.RequestCount("getStationSchedule"))
+}
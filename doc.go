@@ -9,8 +9,15 @@ so it is essential to manage the API token properly to avoid spurious token crea
 
 This library takes care of token management for you. It can receive a token to use throughout
 the session, or it can create one by itself. It can also create a new token automatically when
-the old token expires. When it gets a new token, it will call a function you provide so you can
-save the token for later.
+the old token expires. When it gets a new token, it will save it to a [TokenStore] you provide,
+or call a function you provide, so you can use the token later.
+
+If several processes share the same [TokenStore], the client checks the stored token before creating a
+new one, so only one of the processes needs to create a new token when the old one expires.
+
+The client also learns how long tokens last, and you can see this information with [Client.GetTokenInfo].
+If you use the [WithProactiveTokenRefresh] option, the client creates a new token shortly before the old one
+is expected to expire, so your requests don't have to wait for a new token.
 
 # Enriched API
 
@@ -26,12 +33,68 @@ Similarly, dates and times are represented as [time.Time] objects, delays and dw
 represented as [time.Duration], true/false and yes/no values are represented as booleans,
 we have a special type for colors, and optional values are represented as pointers.
 
+Fields that can't be parsed are left empty and reported as a [ParseWarning] in the Info field of the
+response. If you would rather get an error, use the [WithParseOptions] option to enable strict parsing.
+
+If you need to see what the RailData API actually returned, for example to check the enrichment or to read
+fields this library doesn't model, use [Client.Raw] to get the responses from the API along with the enriched ones.
+
+# Response caching
+
+Many applications request the same information over and over again, for example to display a departure
+board on several screens. You can give the client a [Cache] with the [WithCache] option so it can reuse
+recent responses instead of contacting the server every time. This library provides an in-memory cache
+([NewMemoryCache]) and a cache that stores responses in a directory ([NewFileCache]).
+
+Each method has its own default time-to-live, listed in [DefaultCacheTTLs]; you can change it with the
+[WithCacheTTL] option. Every response has an Info field that tells you whether it came from the cache
+and how old it is.
+
+If the RailData API is failing, the [WithStaleFallback] option makes the client return the last good response
+for the same call instead of an error, marked with the Stale field in its Info, as long as it's not too old.
+
+If many goroutines make the same call at the same time, the [WithRequestCoalescing] option makes them share
+a single request to the RailData API.
+
+# Interceptors
+
+You can use the [WithInterceptor] option to add functions that wrap every call to the RailData API.
+An [Interceptor] sees the method name, its input (without the token or credentials), its output, error and latency,
+and it can modify them or skip the call entirely. This is useful for logging, metrics, auditing,
+or to inject faults in tests.
+The [github.com/jtarrio/raildata/metrics] package uses an interceptor to export Prometheus metrics.
+
+# Logging
+
+The [WithLogger] option gives the client a [log/slog.Logger] to record every call to the RailData API
+(method, duration, status and response size), retries, token refreshes, failing token update listeners,
+and stations or lines that had to be synthesized while parsing. Tokens and passwords are never logged.
+
+# Tracing
+
+The [WithTracer] option makes the client start a span for every call to the RailData API, with attributes
+for the method, station, line and train id in the request, the HTTP status, the number of retries, and whether
+the token had to be refreshed. The [github.com/jtarrio/raildata/otel] module provides a [Tracer] that reports
+these spans to OpenTelemetry; it is a separate module so you don't depend on OpenTelemetry unless you use it.
+
 # Rate-limited functions
 
 Some RailData API methods can only be called 5 or 10 times per day. This library splits them out
 to a separate interface that you can get by calling the [Client.RateLimitedMethods] method. This makes
 it clear to you, the programmer, that you should try to avoid calling those methods too often.
 
+The client counts the calls made to these methods, and to the method that creates tokens, and refuses
+to make a call that would go over the daily limit, returning a
+[github.com/jtarrio/raildata/errors.QuotaExceededError] instead.
+The counts are kept in memory unless you provide a [QuotaStore] with the [WithQuotaStore] option;
+use [NewFileQuotaStore] to share the counts among several processes. The limits are listed in
+[DefaultQuotaLimits] and you can change them with the [WithQuotaLimit] option.
+
+Independently of the daily limits, you can use the [WithRateLimiter] option to limit how fast the client
+makes calls, globally and per method, either waiting until a call can be made or failing fast with a
+[github.com/jtarrio/raildata/errors.RateLimitedError]. Use a [NewFileRateLimitStore] to make several processes
+that share the same credentials respect a combined budget.
+
 # NJ Transit developer credentials
 
 In order to use this library, you need to visit https://developer.njtransit.com/registration/login
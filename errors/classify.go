@@ -0,0 +1,48 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+)
+
+// Classify returns a short name for the class of the given error, suitable for logs and metrics.
+// It returns "ok" for a nil error.
+func Classify(err error) string {
+	var qerr *QuotaExceededError
+	var lerr *RateLimitedError
+	var herr *HttpStatusError
+	var terr *TransportError
+	var derr *DecodeError
+	var perr *ParseError
+	var rerr *RailDataError
+	switch {
+	case err == nil:
+		return "ok"
+	case stderrors.Is(err, context.Canceled):
+		return "canceled"
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case stderrors.Is(err, InvalidTokenError):
+		return "invalid_token"
+	case stderrors.Is(err, MissingCredentialsError):
+		return "missing_credentials"
+	case stderrors.Is(err, BadCredentialsError):
+		return "bad_credentials"
+	case stderrors.As(err, &qerr):
+		return "quota_exceeded"
+	case stderrors.As(err, &lerr):
+		return "rate_limited"
+	case stderrors.As(err, &rerr):
+		return "api_error"
+	case stderrors.As(err, &herr):
+		return "http_status"
+	case stderrors.As(err, &terr):
+		return "transport"
+	case stderrors.As(err, &derr):
+		return "decode"
+	case stderrors.As(err, &perr):
+		return "parse"
+	default:
+		return "other"
+	}
+}
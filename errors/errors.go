@@ -1,5 +1,13 @@
+// Package errors contains the errors returned by the raildata library.
+//
+// All errors can be examined with the standard library's [errors.Is] and [errors.As] functions.
 package errors
 
+import (
+	"fmt"
+	"time"
+)
+
 var (
 	BadCredentialsError     error = &badCredentialsError{}     // invalid username or password.
 	MissingCredentialsError error = &missingCredentialsError{} // token not present or malformed.
@@ -20,6 +28,180 @@ func (e *RailDataError) Error() string {
 	return e.message
 }
 
+// NewHttpStatusError reports that the RailData API returned an unsuccessful HTTP status code.
+// The cause, if not nil, is the error message received from the RailData API.
+func NewHttpStatusError(method string, statusCode int, status string, cause error) *HttpStatusError {
+	return &HttpStatusError{Method: method, StatusCode: statusCode, Status: status, cause: cause}
+}
+
+// HttpStatusError reports that the RailData API returned an unsuccessful HTTP status code.
+//
+// When the RailData API also returns an error message, it is available through [errors.Unwrap]
+// as a [RailDataError], an [InvalidTokenError] or a [QuotaExceededError], so you can examine it
+// with [errors.Is] and [errors.As].
+type HttpStatusError struct {
+	// Method contains the name of the RailData API method.
+	Method string
+	// StatusCode contains the HTTP status code.
+	StatusCode int
+	// Status contains the HTTP status line, for example "502 Bad Gateway".
+	Status string
+	cause  error
+}
+
+func (e *HttpStatusError) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("received error status code for %s: %s: %s", e.Method, e.Status, e.cause.Error())
+	}
+	return fmt.Sprintf("received error status code for %s: %s", e.Method, e.Status)
+}
+
+func (e *HttpStatusError) Unwrap() error {
+	return e.cause
+}
+
+// Temporary returns whether the status code indicates a condition that may go away if the request is retried.
+// It returns false when the RailData API returned an error message, since the message describes the problem.
+func (e *HttpStatusError) Temporary() bool {
+	return e.cause == nil && (e.StatusCode == 429 || e.StatusCode >= 500)
+}
+
+// NewDecodeError reports that the response from the RailData API could not be decoded.
+// Only the first part of the body is kept in the error.
+func NewDecodeError(method string, body []byte, cause error) *DecodeError {
+	excerpt := body
+	if len(excerpt) > maxBodyExcerpt {
+		excerpt = excerpt[:maxBodyExcerpt]
+	}
+	return &DecodeError{Method: method, BodyExcerpt: string(excerpt), cause: cause}
+}
+
+const maxBodyExcerpt = 256
+
+// DecodeError reports that the response from the RailData API could not be decoded.
+type DecodeError struct {
+	// Method contains the name of the RailData API method.
+	Method string
+	// BodyExcerpt contains the first bytes of the response body.
+	BodyExcerpt string
+	cause       error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("could not unmarshal response for %s: %s", e.Method, e.cause.Error())
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.cause
+}
+
+// NewTransportError reports that a request to the RailData API could not be sent or its response could not be received.
+func NewTransportError(method string, cause error) *TransportError {
+	return &TransportError{Method: method, cause: cause}
+}
+
+// TransportError reports that a request to the RailData API could not be sent or its response could not be received.
+//
+// The underlying error is available through [errors.Unwrap], so you can check for context cancellation with [errors.Is].
+type TransportError struct {
+	// Method contains the name of the RailData API method.
+	Method string
+	cause  error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("error issuing request for method '%s': %s", e.Method, e.cause.Error())
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.cause
+}
+
+// NewTrainNotFoundError reports that the RailData API doesn't know about a train.
+func NewTrainNotFoundError(trainId string) *TrainNotFoundError {
+	return &TrainNotFoundError{TrainId: trainId}
+}
+
+// TrainNotFoundError reports that the RailData API doesn't know about a train.
+type TrainNotFoundError struct {
+	// TrainId contains the train's number.
+	TrainId string
+}
+
+func (e *TrainNotFoundError) Error() string {
+	return fmt.Sprintf("train %s not found", e.TrainId)
+}
+
+// NewQuotaExceededError reports that the daily usage limit for a RailData API method has been reached.
+// The cause, if not nil, is the error received from the RailData API.
+func NewQuotaExceededError(method string, limit int, usage int, resetTime time.Time, cause error) *QuotaExceededError {
+	return &QuotaExceededError{Method: method, Limit: limit, Usage: usage, ResetTime: resetTime, cause: cause}
+}
+
+// QuotaExceededError reports that the daily usage limit for a RailData API method has been reached.
+//
+// This error can be produced by the RailData API or by the client, which keeps track of the daily usage
+// and refuses to make calls that would go over the limit.
+type QuotaExceededError struct {
+	// Method contains the name of the RailData API method.
+	Method string
+	// Limit contains the number of calls allowed per day.
+	Limit int
+	// Usage contains the number of calls made today.
+	Usage int
+	// ResetTime contains the date/time when the usage count will be reset, or the zero time if it's not known.
+	ResetTime time.Time
+	cause     error
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("daily usage limit for %s exceeded: %d calls made, %d allowed", e.Method, e.Usage, e.Limit)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return e.cause
+}
+
+// NewRateLimitedError reports that a call was not made because it would go over the client's rate limit.
+func NewRateLimitedError(method string, retryAfter time.Duration) *RateLimitedError {
+	return &RateLimitedError{Method: method, RetryAfter: retryAfter}
+}
+
+// RateLimitedError reports that a call was not made because it would go over the client's rate limit.
+//
+// This error is only returned when the rate limiter is configured to fail fast instead of waiting.
+type RateLimitedError struct {
+	// Method contains the name of the RailData API method.
+	Method string
+	// RetryAfter contains how long to wait before the call can be made.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limit for %s exceeded, retry after %s", e.Method, e.RetryAfter)
+}
+
+// NewParseError reports that a field in a RailData API response could not be parsed.
+func NewParseError(field string, value string, reason string) *ParseError {
+	return &ParseError{Field: field, Value: value, Reason: reason}
+}
+
+// ParseError reports that a field in a RailData API response could not be parsed.
+//
+// This error is only returned when strict parsing is enabled.
+type ParseError struct {
+	// Field contains the path to the field, for example "ITEMS[3].STOPS[0].TIME".
+	Field string
+	// Value contains the raw value of the field.
+	Value string
+	// Reason contains a description of the problem.
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("could not parse field %s with value %q: %s", e.Field, e.Value, e.Reason)
+}
+
 type badCredentialsError struct{}
 
 func (e *badCredentialsError) Error() string {
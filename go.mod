@@ -2,7 +2,10 @@ module github.com/jtarrio/raildata
 
 go 1.23.4
 
-require github.com/stretchr/testify v1.10.0
+require (
+	github.com/rogpeppe/go-internal v1.13.1
+	github.com/stretchr/testify v1.10.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
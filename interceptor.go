@@ -0,0 +1,103 @@
+package raildata
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jtarrio/raildata/api"
+)
+
+// Call describes a call to a RailData API method.
+type Call struct {
+	// Method contains the name of the RailData API method.
+	Method string
+	// Input contains a pointer to the method's input, which is one of the request types in the api package.
+	// The token, username, and password are always empty; the client fills them in after all the interceptors have run.
+	// Interceptors may replace the input with another value of the same type.
+	Input any
+}
+
+// CallResult contains the result of a call to a RailData API method.
+type CallResult struct {
+	// Output contains a pointer to the decoded response, which is one of the response types in the api package.
+	Output any
+	// Raw contains the JSON document received from the RailData server.
+	Raw []byte
+	// Err contains the error returned by the call, if any.
+	Err error
+	// Latency contains how long the call took, including retries and token refreshes.
+	Latency time.Duration
+}
+
+// Invoker is the type of a function that performs a call to a RailData API method.
+type Invoker func(ctx context.Context, call *Call) CallResult
+
+// Interceptor is the type of a function that wraps calls to RailData API methods.
+//
+// An interceptor usually calls next to perform the call, and it can examine or modify the call before
+// and the result after it. It can also return a result without calling next, for example to serve
+// a response from its own cache or to inject a fault.
+//
+// If an interceptor returns a result with an Output of the wrong type, the client decodes Raw instead.
+type Interceptor func(ctx context.Context, call *Call, next Invoker) CallResult
+
+// WithInterceptor adds an interceptor to the chain that wraps every call to the RailData API.
+//
+// Interceptors run in the order they were added: the first one is the outermost.
+// Calls served from the client's cache (see [WithCache]) don't go through the interceptors.
+// The calls the client makes to create tokens also go through the interceptors.
+func WithInterceptor(interceptor Interceptor) Option {
+	return func(s *raildataClient) {
+		s.interceptors = append(s.interceptors, interceptor)
+	}
+}
+
+// invoke calls fetch through the interceptor chain.
+func invoke[I any, O any](method api.MethodDefinition[I, O], s *raildataClient, ctx context.Context, input *I) (*O, []byte, error) {
+	if len(s.interceptors) == 0 {
+		return fetch(method, s, ctx, input)
+	}
+	redacted := *input
+	method.SetToken(&redacted, "")
+	return intercept(method, s, ctx, &redacted, func(ctx context.Context, req *I) (*O, []byte, error) {
+		return fetch(method, s, ctx, req)
+	})
+}
+
+// intercept calls do through the interceptor chain. The input must not contain any secrets;
+// do receives a copy of the input the interceptors passed on, and must fill them in.
+func intercept[I any, O any](method api.MethodDefinition[I, O], s *raildataClient, ctx context.Context, input *I, do func(context.Context, *I) (*O, []byte, error)) (*O, []byte, error) {
+	invoker := func(ctx context.Context, call *Call) CallResult {
+		in, ok := call.Input.(*I)
+		if !ok {
+			return CallResult{Err: fmt.Errorf("interceptor provided input of type %T for %s", call.Input, method.Name)}
+		}
+		req := *in
+		start := time.Now()
+		out, raw, err := do(ctx, &req)
+		return CallResult{Output: out, Raw: raw, Err: err, Latency: time.Since(start)}
+	}
+	for i := len(s.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := s.interceptors[i], invoker
+		invoker = func(ctx context.Context, call *Call) CallResult {
+			return interceptor(ctx, call, next)
+		}
+	}
+
+	result := invoker(ctx, &Call{Method: method.Name, Input: input})
+	if result.Err != nil {
+		return nil, nil, result.Err
+	}
+	if out, ok := result.Output.(*O); ok && out != nil {
+		return out, result.Raw, nil
+	}
+	if result.Raw == nil {
+		return nil, nil, fmt.Errorf("interceptor provided output of type %T for %s", result.Output, method.Name)
+	}
+	out, err := method.Decode(result.Raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, result.Raw, nil
+}
@@ -0,0 +1,123 @@
+package raildata_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/api"
+	"github.com/jtarrio/raildata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptorsRunInOrder(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getStationMSG", "token", testToken, "station", "NY").sendJson(`[]`))
+
+	var log []string
+	recorder := func(name string) raildata.Interceptor {
+		return func(ctx context.Context, call *raildata.Call, next raildata.Invoker) raildata.CallResult {
+			log = append(log, name+" before "+call.Method)
+			result := next(ctx, call)
+			log = append(log, name+" after "+call.Method)
+			return result
+		}
+	}
+	var seen raildata.CallResult
+	inspector := func(ctx context.Context, call *raildata.Call, next raildata.Invoker) raildata.CallResult {
+		input, ok := call.Input.(*api.GetStationMsgRequest)
+		require.True(t, ok)
+		assert.Empty(t, input.Token)
+		assert.Equal(t, "NY", input.Station)
+		seen = next(ctx, call)
+		return seen
+	}
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken),
+		raildata.WithInterceptor(recorder("first")), raildata.WithInterceptor(recorder("second")), raildata.WithInterceptor(inspector))
+	require.NoError(t, err)
+
+	code := raildata.StationCode("NY")
+	_, err = client.GetStationMsg(context.Background(), &raildata.GetStationMsgRequest{StationCode: &code})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first before getStationMSG", "second before getStationMSG", "second after getStationMSG", "first after getStationMSG"}, log)
+	assert.NoError(t, seen.Err)
+	assert.Equal(t, `[]`, string(seen.Raw))
+	assert.IsType(t, &[]api.StationMsgs{}, seen.Output)
+	assert.Positive(t, seen.Latency)
+}
+
+func TestInterceptorCanInjectFaults(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getVehicleData").sendJson(`[]`))
+
+	calls := 0
+	faulty := func(ctx context.Context, call *raildata.Call, next raildata.Invoker) raildata.CallResult {
+		calls++
+		if calls == 1 {
+			return raildata.CallResult{Err: errors.NewRailDataError("injected")}
+		}
+		return next(ctx, call)
+	}
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithInterceptor(faulty))
+	require.NoError(t, err)
+
+	_, err = client.GetVehicleData(context.Background())
+	assert.EqualError(t, err, "injected")
+	_, err = client.GetVehicleData(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestInterceptorCanReplaceResponse(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getStationList").sendJson(`[]`))
+
+	fake := func(ctx context.Context, call *raildata.Call, next raildata.Invoker) raildata.CallResult {
+		return raildata.CallResult{Raw: []byte(`[{"STATION_2CHAR": "NY", "STATIONNAME": "New York Penn Station", "STATION_14CHAR": "New York"}]`)}
+	}
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithInterceptor(fake))
+	require.NoError(t, err)
+
+	actual, err := client.GetStationList(context.Background())
+	require.NoError(t, err)
+	require.Len(t, actual.Stations, 1)
+	assert.Equal(t, raildata.StationCode("NY"), actual.Stations[0].Code)
+}
+
+func TestInterceptorSeesTokenCreation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/getStationList":
+			require.NoError(t, req.ParseMultipartForm(5000000))
+			if req.Form.Get("token") == "newtoken" {
+				expectRequest(t, "getStationList").sendJson(`[]`).ServeHTTP(rw, req)
+			} else {
+				expectRequest(t, "getStationList").sendError("Invalid token.").ServeHTTP(rw, req)
+			}
+		case "/getToken":
+			expectRequest(t, "getToken", "username", "the-user-id", "password", "the-password").sendJson(`{
+ "Authenticated": "True",
+ "UserToken": "newtoken"
+}`).ServeHTTP(rw, req)
+		}
+	}))
+
+	var methods []string
+	inspector := func(ctx context.Context, call *raildata.Call, next raildata.Invoker) raildata.CallResult {
+		methods = append(methods, call.Method)
+		if input, ok := call.Input.(*api.GetTokenRequest); ok {
+			assert.Empty(t, input.Username)
+			assert.Empty(t, input.Password)
+		}
+		return next(ctx, call)
+	}
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken("oldtoken"),
+		raildata.WithCredentials("the-user-id", "the-password"), raildata.WithInterceptor(inspector))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "newtoken", client.GetToken())
+	// The token is created during the getStationList call.
+	assert.Equal(t, []string{"getStationList", "getToken"}, methods)
+}
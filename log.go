@@ -0,0 +1,60 @@
+package raildata
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	rderrors "github.com/jtarrio/raildata/errors"
+)
+
+// WithLogger sets a logger for the client.
+//
+// The client logs every call to the RailData API at the debug level (or the warning level if it fails),
+// retries and token refreshes at the info level, and failures in token stores and listeners at the error level.
+// It also logs, at the debug level, when it synthesizes a station or line it couldn't find.
+// Tokens and passwords are never logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *raildataClient) {
+		s.logger = logger
+	}
+}
+
+// discardHandler is a [slog.Handler] that discards all records.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// logCall logs a call to the RailData API.
+func (s *raildataClient) logCall(ctx context.Context, method string, duration time.Duration, raw []byte, err error) {
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.Duration("duration", duration),
+		slog.String("status", rderrors.Classify(err)),
+	}
+	if err != nil {
+		var herr *rderrors.HttpStatusError
+		if errors.As(err, &herr) {
+			attrs = append(attrs, slog.Int("http_status", herr.StatusCode))
+		}
+		attrs = append(attrs, slog.String("error", err.Error()))
+		s.logger.LogAttrs(ctx, slog.LevelWarn, "RailData API call failed", attrs...)
+		return
+	}
+	attrs = append(attrs, slog.Int("size", len(raw)))
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "RailData API call", attrs...)
+}
+
+// callListener calls a token update listener, logging it if it panics.
+func (s *raildataClient) callListener(listener TokenUpdateListener, token string, oldToken string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("token update listener failed", "panic", r)
+		}
+	}()
+	listener(token, oldToken)
+}
@@ -0,0 +1,157 @@
+package raildata_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logBuffer collects the records written by a JSON slog handler.
+type logBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func (b *logBuffer) records(t *testing.T) []map[string]any {
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		delete(record, "time")
+		out = append(out, record)
+	}
+	return out
+}
+
+func newTestLogger(buf *logBuffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestLoggerRecordsCalls(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getStationList").sendJson(`[{"STATION_2CHAR": "NY", "STATIONNAME": "New York"}]`))
+	defer server.Close()
+
+	var buf logBuffer
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithLogger(newTestLogger(&buf)))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+
+	records := buf.records(t)
+	require.Len(t, records, 1)
+	assert.Equal(t, "DEBUG", records[0]["level"])
+	assert.Equal(t, "RailData API call", records[0]["msg"])
+	assert.Equal(t, "getStationList", records[0]["method"])
+	assert.Equal(t, "ok", records[0]["status"])
+	assert.Equal(t, float64(52), records[0]["size"])
+	assert.Contains(t, records[0], "duration")
+	assert.NotContains(t, buf.String(), testToken)
+}
+
+func TestLoggerRecordsFailuresAndTokenRefreshes(t *testing.T) {
+	var tokenRequests atomic.Int32
+	release := make(chan struct{})
+	close(release)
+	server := newExpiringTokenServer(t, &tokenRequests, release)
+	defer server.Close()
+
+	var buf logBuffer
+	listenerCalled := make(chan struct{})
+	client, err := raildata.NewClient(
+		withServerUrl(t, server),
+		raildata.WithToken("oldtoken"),
+		raildata.WithCredentials("the-user-id", "the-password"),
+		raildata.WithLogger(newTestLogger(&buf)),
+		raildata.WithTokenUpdateListener(func(token string, oldToken string) {
+			close(listenerCalled)
+			panic("listener failed")
+		}))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+	<-listenerCalled
+	require.Eventually(t, func() bool { return strings.Contains(buf.String(), "listener failed") }, time.Second, time.Millisecond)
+
+	var messages []string
+	for _, record := range buf.records(t) {
+		messages = append(messages, record["level"].(string)+" "+record["msg"].(string))
+	}
+	// The listener runs in its own goroutine, so its record may appear anywhere after the token was created.
+	assert.ElementsMatch(t, []string{
+		"WARN RailData API call failed",
+		"DEBUG RailData API call",
+		"INFO created new token",
+		"DEBUG RailData API call",
+		"ERROR token update listener failed",
+	}, messages)
+
+	failed := buf.records(t)[0]
+	assert.Equal(t, "getStationList", failed["method"])
+	assert.Equal(t, "invalid_token", failed["status"])
+
+	log := buf.String()
+	assert.NotContains(t, log, "oldtoken")
+	assert.NotContains(t, log, "newtoken")
+	assert.NotContains(t, log, "the-password")
+}
+
+func TestLoggerRecordsParseFallbacks(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getStationMSG").sendJson(`[
+  {
+    "MSG_TYPE": "banner",
+    "MSG_TEXT": "Text of the message.",
+    "MSG_PUBDATE": "1/17/2025 2:40:00 PM",
+    "MSG_STATION_SCOPE": "*Xqzzy",
+    "MSG_LINE_SCOPE": ""
+  }
+]`))
+	defer server.Close()
+
+	var buf logBuffer
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithLogger(newTestLogger(&buf)))
+	require.NoError(t, err)
+
+	_, err = client.GetStationMsg(context.Background(), &raildata.GetStationMsgRequest{})
+	require.NoError(t, err)
+
+	records := buf.records(t)
+	require.Len(t, records, 2)
+	assert.Equal(t, "synthesized station", records[1]["msg"])
+	assert.Equal(t, "[0].MSG_STATION_SCOPE", records[1]["field"])
+	assert.Equal(t, "Xqzzy", records[1]["name"])
+}
+
+func TestClassify(t *testing.T) {
+	assert.Equal(t, "ok", errors.Classify(nil))
+	assert.Equal(t, "canceled", errors.Classify(context.Canceled))
+	assert.Equal(t, "timeout", errors.Classify(context.DeadlineExceeded))
+	assert.Equal(t, "invalid_token", errors.Classify(errors.InvalidTokenError))
+	assert.Equal(t, "http_status", errors.Classify(&errors.HttpStatusError{StatusCode: 503}))
+	assert.Equal(t, "other", errors.Classify(assert.AnError))
+}
@@ -0,0 +1,229 @@
+// Package metrics collects statistics about a RailData client's activity and exposes them
+// in the Prometheus text exposition format, without depending on the Prometheus client library.
+//
+// Example:
+//
+//	m := metrics.New()
+//	client, err := raildata.NewClient(append(m.ClientOptions(), raildata.WithCredentials(username, password))...)
+//	if err != nil { return err }
+//	m.WatchQuota(client)
+//	http.Handle("/metrics", m.Handler())
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	rderrors "github.com/jtarrio/raildata/errors"
+)
+
+// DefaultBuckets contains the default upper bounds, in seconds, of the latency histogram buckets.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Metrics collects statistics about the calls made by one or more RailData clients.
+type Metrics struct {
+	buckets []float64
+
+	mutex          sync.Mutex
+	calls          map[callKey]int
+	latencies      map[string]*histogram
+	tokenRefreshes int
+	quotaSources   []QuotaSource
+}
+
+// QuotaSource is implemented by types that can report their daily usage quota, such as [raildata.Client].
+type QuotaSource interface {
+	GetQuotaUsage() ([]raildata.QuotaUsage, error)
+}
+
+type callKey struct {
+	method string
+	status string
+}
+
+type histogram struct {
+	counts []int
+	sum    float64
+	count  int
+}
+
+// Option is the type of the options that can be passed to [New].
+type Option func(*Metrics)
+
+// WithBuckets sets the upper bounds, in seconds, of the latency histogram buckets.
+func WithBuckets(buckets []float64) Option {
+	return func(m *Metrics) {
+		m.buckets = slices.Sorted(slices.Values(buckets))
+	}
+}
+
+// New creates a [Metrics] collector.
+func New(options ...Option) *Metrics {
+	m := &Metrics{
+		buckets:   DefaultBuckets,
+		calls:     map[callKey]int{},
+		latencies: map[string]*histogram{},
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+	return m
+}
+
+// ClientOptions returns the options that make a RailData client report its activity to this collector.
+//
+// Calls served from the client's cache are not counted.
+func (m *Metrics) ClientOptions() []raildata.Option {
+	return []raildata.Option{
+		raildata.WithInterceptor(m.Interceptor()),
+		raildata.WithTokenUpdateListener(m.TokenUpdateListener()),
+	}
+}
+
+// Interceptor returns an interceptor that records the number of calls, their status, and their latency.
+func (m *Metrics) Interceptor() raildata.Interceptor {
+	return func(ctx context.Context, call *raildata.Call, next raildata.Invoker) raildata.CallResult {
+		start := time.Now()
+		result := next(ctx, call)
+		m.observeCall(call.Method, rderrors.Classify(result.Err), time.Since(start))
+		return result
+	}
+}
+
+// TokenUpdateListener returns a listener that counts the tokens created by the client.
+func (m *Metrics) TokenUpdateListener() raildata.TokenUpdateListener {
+	return func(newToken string, previousToken string) {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		m.tokenRefreshes++
+	}
+}
+
+// WatchQuota makes the collector report the daily quota usage of the given client.
+func (m *Metrics) WatchQuota(source QuotaSource) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.quotaSources = append(m.quotaSources, source)
+}
+
+func (m *Metrics) observeCall(method string, status string, latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.calls[callKey{method: method, status: status}]++
+	h, ok := m.latencies[method]
+	if !ok {
+		h = &histogram{counts: make([]int, len(m.buckets))}
+		m.latencies[method] = h
+	}
+	seconds := latency.Seconds()
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// Handler returns an HTTP handler that serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteTo(rw)
+	})
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	m.mutex.Lock()
+	sources := slices.Clone(m.quotaSources)
+
+	writeHeader(&b, "raildata_calls_total", "counter", "Number of calls to the RailData API, by method and status.")
+	keys := slices.SortedFunc(maps.Keys(m.calls), func(a, b callKey) int {
+		if c := strings.Compare(a.method, b.method); c != 0 {
+			return c
+		}
+		return strings.Compare(a.status, b.status)
+	})
+	for _, key := range keys {
+		writeSample(&b, "raildata_calls_total", labels("method", key.method, "status", key.status), float64(m.calls[key]))
+	}
+
+	writeHeader(&b, "raildata_call_duration_seconds", "histogram", "Latency of the calls to the RailData API, including retries and token refreshes.")
+	for _, method := range slices.Sorted(maps.Keys(m.latencies)) {
+		h := m.latencies[method]
+		for i, bound := range m.buckets {
+			writeSample(&b, "raildata_call_duration_seconds_bucket", labels("method", method, "le", formatFloat(bound)), float64(h.counts[i]))
+		}
+		writeSample(&b, "raildata_call_duration_seconds_bucket", labels("method", method, "le", "+Inf"), float64(h.count))
+		writeSample(&b, "raildata_call_duration_seconds_sum", labels("method", method), h.sum)
+		writeSample(&b, "raildata_call_duration_seconds_count", labels("method", method), float64(h.count))
+	}
+
+	writeHeader(&b, "raildata_token_refreshes_total", "counter", "Number of tokens created.")
+	writeSample(&b, "raildata_token_refreshes_total", "", float64(m.tokenRefreshes))
+	m.mutex.Unlock()
+
+	// The quota sources may block on a file, so they are queried without holding the lock.
+	var usage []raildata.QuotaUsage
+	var quotaErrors int
+	for _, source := range sources {
+		u, err := source.GetQuotaUsage()
+		if err != nil {
+			quotaErrors++
+			continue
+		}
+		usage = append(usage, u...)
+	}
+	writeHeader(&b, "raildata_quota_usage", "gauge", "Number of calls made today to methods with a daily usage limit.")
+	for _, u := range usage {
+		writeSample(&b, "raildata_quota_usage", labels("method", u.Method), float64(u.Usage))
+	}
+	writeHeader(&b, "raildata_quota_limit", "gauge", "Number of calls allowed per day to methods with a daily usage limit.")
+	for _, u := range usage {
+		writeSample(&b, "raildata_quota_limit", labels("method", u.Method), float64(u.Limit))
+	}
+	writeHeader(&b, "raildata_quota_reset_timestamp_seconds", "gauge", "Time when the daily usage counts will be reset.")
+	for _, u := range usage {
+		writeSample(&b, "raildata_quota_reset_timestamp_seconds", labels("method", u.Method), float64(u.ResetTime.Unix()))
+	}
+	writeHeader(&b, "raildata_quota_errors", "gauge", "Number of quota sources that could not be read during this scrape.")
+	writeSample(&b, "raildata_quota_errors", "", float64(quotaErrors))
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func writeHeader(b *strings.Builder, name string, kind string, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeSample(b *strings.Builder, name string, labels string, value float64) {
+	fmt.Fprintf(b, "%s%s %s\n", name, labels, formatFloat(value))
+}
+
+// labels formats a list of alternating label names and values.
+func labels(nameValues ...string) string {
+	var parts []string
+	for i := 0; i+1 < len(nameValues); i += 2 {
+		parts = append(parts, nameValues[i]+`="`+labelEscaper.Replace(nameValues[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// labelEscaper escapes label values as specified by the Prometheus text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,82 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/metrics"
+	"github.com/jtarrio/raildata/raildatatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	server.InjectFault("getVehicleData", raildatatest.Fault{StatusCode: 503, Count: 1})
+
+	m := metrics.New(metrics.WithBuckets([]float64{60, 1}))
+	options := append(server.ClientOptions(), raildata.WithToken("stale-token"))
+	client, err := raildata.NewClient(append(options, m.ClientOptions()...)...)
+	require.NoError(t, err)
+	m.WatchQuota(client)
+
+	ctx := context.Background()
+	_, err = client.GetStationList(ctx)
+	require.NoError(t, err)
+	_, err = client.GetStationList(ctx)
+	require.NoError(t, err)
+	_, err = client.GetVehicleData(ctx)
+	require.Error(t, err)
+	_, err = client.RateLimitedMethods().IsValidToken(ctx)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(scrape(t, m), "raildata_token_refreshes_total 1\n")
+	}, time.Second, time.Millisecond)
+
+	lines := strings.Split(scrape(t, m), "\n")
+	assert.Contains(t, lines, "# TYPE raildata_calls_total counter")
+	assert.Contains(t, lines, `raildata_calls_total{method="getStationList",status="ok"} 2`)
+	assert.Contains(t, lines, `raildata_calls_total{method="getVehicleData",status="http_status"} 1`)
+	assert.Contains(t, lines, `raildata_calls_total{method="isValidToken",status="ok"} 1`)
+	assert.Contains(t, lines, `raildata_calls_total{method="getToken",status="ok"} 1`)
+	assert.Contains(t, lines, "# TYPE raildata_call_duration_seconds histogram")
+	assert.Contains(t, lines, `raildata_call_duration_seconds_bucket{method="getStationList",le="1"} 2`)
+	assert.Contains(t, lines, `raildata_call_duration_seconds_bucket{method="getStationList",le="60"} 2`)
+	assert.Contains(t, lines, `raildata_call_duration_seconds_bucket{method="getStationList",le="+Inf"} 2`)
+	assert.Contains(t, lines, `raildata_call_duration_seconds_count{method="getStationList"} 2`)
+	assert.Contains(t, lines, `raildata_quota_usage{method="getToken"} 1`)
+	assert.Contains(t, lines, `raildata_quota_usage{method="isValidToken"} 1`)
+	assert.Contains(t, lines, `raildata_quota_limit{method="getStationSchedule"} 5`)
+	assert.Contains(t, lines, "raildata_quota_errors 0")
+}
+
+func TestMetricsEscapesLabels(t *testing.T) {
+	m := metrics.New()
+	client, err := raildata.NewClient(raildata.WithToken("token"), raildata.WithQuotaLimit("odd\t\"name\"\\\n", 3))
+	require.NoError(t, err)
+	m.WatchQuota(client)
+
+	lines := strings.Split(scrape(t, m), "\n")
+	// Only backslashes, double quotes and newlines are escaped.
+	assert.Contains(t, lines, `raildata_quota_limit{method="odd`+"\t"+`\"name\"\\\n"} 3`)
+}
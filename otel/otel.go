@@ -0,0 +1,78 @@
+// Package otel reports the calls that a RailData client makes to OpenTelemetry.
+//
+// It is a separate module so that programs that don't use OpenTelemetry don't need to depend on it.
+//
+// Example:
+//
+//	client, err := raildata.NewClient(
+//		raildata.WithTracer(otel.NewTracer(otel.DefaultTracerProvider())),
+//		...
+//	)
+package otel
+
+import (
+	"context"
+
+	"github.com/jtarrio/raildata"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName contains the name of the instrumentation scope for the spans created by this package.
+const InstrumentationName = "github.com/jtarrio/raildata"
+
+// DefaultTracerProvider returns the global OpenTelemetry tracer provider.
+func DefaultTracerProvider() trace.TracerProvider {
+	return otel.GetTracerProvider()
+}
+
+// NewTracer returns a [raildata.Tracer] that creates OpenTelemetry spans using the given provider.
+func NewTracer(provider trace.TracerProvider) raildata.Tracer {
+	return &tracer{tracer: provider.Tracer(InstrumentationName)}
+}
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+func (t *tracer) Start(ctx context.Context, name string, attributes ...raildata.TraceAttribute) (context.Context, raildata.Span) {
+	ctx, s := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(convert(attributes)...))
+	return ctx, &span{span: s}
+}
+
+type span struct {
+	span trace.Span
+}
+
+func (s *span) SetAttributes(attributes ...raildata.TraceAttribute) {
+	s.span.SetAttributes(convert(attributes)...)
+}
+
+func (s *span) AddEvent(name string, attributes ...raildata.TraceAttribute) {
+	s.span.AddEvent(name, trace.WithAttributes(convert(attributes)...))
+}
+
+func (s *span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+func convert(attributes []raildata.TraceAttribute) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(attributes))
+	for _, a := range attributes {
+		switch v := a.Value.(type) {
+		case string:
+			out = append(out, attribute.String(a.Key, v))
+		case int:
+			out = append(out, attribute.Int(a.Key, v))
+		case bool:
+			out = append(out, attribute.Bool(a.Key, v))
+		}
+	}
+	return out
+}
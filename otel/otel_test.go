@@ -0,0 +1,91 @@
+package otel_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/otel"
+	"github.com/jtarrio/raildata/raildatatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	server.AddTrain(raildatatest.Train{
+		TrainId: "3887",
+		Line:    raildatatest.Line("NE"),
+		Stops: []raildatatest.Stop{
+			{Station: raildatatest.Station("TR"), Time: raildatatest.At(0)},
+			{Station: raildatatest.Station("NY"), Time: raildatatest.At(60)},
+		},
+	})
+	server.InjectFault("getTrainStopList", raildatatest.Fault{StatusCode: 503, Count: 1})
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	options := append(server.ClientOptions(),
+		raildata.WithToken("stale-token"),
+		raildata.WithTracer(otel.NewTracer(provider)),
+		raildata.WithRetryPolicy(raildata.RetryPolicy{MaxAttempts: 2}))
+	client, err := raildata.NewClient(options...)
+	require.NoError(t, err)
+
+	_, err = client.GetTrainStopList(context.Background(), &raildata.GetTrainStopListRequest{TrainId: "3887"})
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	refresh := spans[0]
+	assert.Equal(t, "raildata.refreshToken", refresh.Name())
+	assert.Contains(t, refresh.Attributes(), attribute.Bool("raildata.token_created", true))
+
+	call := spans[1]
+	assert.Equal(t, "raildata.getTrainStopList", call.Name())
+	assert.Equal(t, call.SpanContext().SpanID(), refresh.Parent().SpanID())
+	assert.Equal(t, codes.Unset, call.Status().Code)
+	assert.Subset(t, call.Attributes(), []attribute.KeyValue{
+		attribute.String(raildata.TraceAttrMethod, "getTrainStopList"),
+		attribute.String(raildata.TraceAttrTrainId, "3887"),
+		attribute.Bool(raildata.TraceAttrCached, false),
+		attribute.Int(raildata.TraceAttrRetryCount, 1),
+		attribute.Bool(raildata.TraceAttrTokenRefreshed, true),
+		attribute.String(raildata.TraceAttrErrorClass, "ok"),
+		attribute.Int(raildata.TraceAttrHttpStatus, 200),
+	})
+	var events []string
+	for _, event := range call.Events() {
+		events = append(events, event.Name)
+	}
+	assert.Equal(t, []string{"retry", "token refresh"}, events)
+}
+
+func TestTracerRecordsErrors(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	server.InjectFault("getStationSchedule", raildatatest.Fault{StatusCode: 404, Count: 1})
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client, err := raildata.NewClient(append(server.ClientOptions(), raildata.WithToken(server.IssueToken()), raildata.WithTracer(otel.NewTracer(provider)))...)
+	require.NoError(t, err)
+
+	_, err = client.RateLimitedMethods().GetStationSchedule(context.Background(), &raildata.GetStationScheduleRequest{StationCode: "NY"})
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Subset(t, spans[0].Attributes(), []attribute.KeyValue{
+		attribute.String(raildata.TraceAttrStation, "NY"),
+		attribute.String(raildata.TraceAttrErrorClass, "http_status"),
+		attribute.Int(raildata.TraceAttrHttpStatus, 404),
+	})
+}
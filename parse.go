@@ -1,12 +1,15 @@
 package raildata
 
 import (
+	"fmt"
 	"html"
+	"log/slog"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/jtarrio/raildata/api"
+	rderrors "github.com/jtarrio/raildata/errors"
 )
 
 var njLocation = func() *time.Location {
@@ -20,20 +23,56 @@ var njLocation = func() *time.Location {
 const msgDateTimeFormat = "1/2/2006 3:04:05 PM"
 const dateTimeFormat = "02-Jan-2006 03:04:05 PM"
 
+// ParseOptions contains options for parsing the responses from the RailData API.
+//
+// The zero value parses leniently: fields that can't be parsed are left empty and a [ParseWarning] is recorded.
+type ParseOptions struct {
+	// Strict makes parsing fail with a [github.com/jtarrio/raildata/errors.ParseError] if any field can't be parsed.
+	Strict bool
+	// Logger, if not nil, receives debug messages when the parser synthesizes a station or line that it couldn't find.
+	Logger *slog.Logger
+}
+
+// ParseWarning describes a field in a RailData API response that could not be parsed.
+type ParseWarning struct {
+	// Field contains the path to the field, for example "ITEMS[3].STOPS[0].TIME".
+	Field string
+	// Value contains the raw value of the field.
+	Value string
+	// Reason contains a description of the problem.
+	Reason string
+}
+
+func (w ParseWarning) String() string {
+	return fmt.Sprintf("%s: %s (value %q)", w.Field, w.Reason, w.Value)
+}
+
 func ParseValidTokenResponse(input *api.ValidTokenResponse) (*IsValidTokenResponse, error) {
+	response, _, err := ParseOptions{}.ParseValidTokenResponse(input)
+	return response, err
+}
+
+// ParseValidTokenResponse parses the response of the isValidToken method. It returns the parsed response and a list of warnings.
+func (o ParseOptions) ParseValidTokenResponse(input *api.ValidTokenResponse) (*IsValidTokenResponse, []ParseWarning, error) {
 	response := &IsValidTokenResponse{
 		ValidToken: input.ValidToken,
 		UserId:     strToPtr(input.UserID),
 	}
-	return response, nil
+	return response, nil, nil
 }
 
 func ParseGetStationsList(input []api.GetStations) (*GetStationListResponse, error) {
+	response, _, err := ParseOptions{}.ParseGetStationsList(input)
+	return response, err
+}
+
+// ParseGetStationsList parses the response of the getStationList method. It returns the parsed response and a list of warnings.
+func (o ParseOptions) ParseGetStationsList(input []api.GetStations) (*GetStationListResponse, []ParseWarning, error) {
 	response := &GetStationListResponse{}
 	for i := range input {
 		response.Stations = append(response.Stations, ParseGetStations(&input[i]))
 	}
-	return response, nil
+	return response, nil, nil
 }
 
 func ParseGetStations(input *api.GetStations) Station {
@@ -46,57 +85,83 @@ func ParseGetStations(input *api.GetStations) Station {
 }
 
 func ParseStationMsgsList(input []api.StationMsgs) *GetStationMsgResponse {
+	response, _, _ := ParseOptions{}.ParseStationMsgsList(input)
+	return response
+}
+
+// ParseStationMsgsList parses the response of the getStationMSG method. It returns the parsed response and a list of warnings.
+func (o ParseOptions) ParseStationMsgsList(input []api.StationMsgs) (*GetStationMsgResponse, []ParseWarning, error) {
+	p := &parser{logger: o.Logger}
 	response := &GetStationMsgResponse{}
 	for i := range input {
-		response.Messages = append(response.Messages, ParseStationMsgs(&input[i]))
+		response.Messages = append(response.Messages, p.stationMsgs(&input[i], index("", i)))
 	}
-	return response
+	return result(p, o, response)
 }
 
 func ParseStationMsgs(input *api.StationMsgs) StationMsg {
+	return (&parser{}).stationMsgs(input, "")
+}
+
+func (p *parser) stationMsgs(input *api.StationMsgs, path string) StationMsg {
 	stationMsg := StationMsg{
-		Type:         strToMsgType(input.MSG_TYPE),
+		Type:         p.msgType(field(path, "MSG_TYPE"), input.MSG_TYPE),
 		Text:         input.MSG_TEXT,
-		PubDate:      *strToLocalTime(input.MSG_PUBDATE, msgDateTimeFormat),
+		PubDate:      p.requiredLocalTime(field(path, "MSG_PUBDATE"), input.MSG_PUBDATE, msgDateTimeFormat),
 		Id:           strToPtr(input.MSG_ID),
 		Agency:       strToPtr(input.MSG_AGENCY),
 		Source:       strToPtr(input.MSG_SOURCE),
-		StationScope: decodeStationScope(input.MSG_STATION_SCOPE),
-		LineScope:    decodeLineScope(input.MSG_LINE_SCOPE),
+		StationScope: p.stationScope(field(path, "MSG_STATION_SCOPE"), input.MSG_STATION_SCOPE),
+		LineScope:    p.lineScope(field(path, "MSG_LINE_SCOPE"), input.MSG_LINE_SCOPE),
 	}
 	return stationMsg
 }
 
 func ParseDailyStationInfoList(input []api.DailyStationInfo) (*GetStationScheduleResponse, error) {
+	response, _, err := ParseOptions{}.ParseDailyStationInfoList(input)
+	return response, err
+}
+
+// ParseDailyStationInfoList parses the response of the getStationSchedule method. It returns the parsed response and a list of warnings.
+func (o ParseOptions) ParseDailyStationInfoList(input []api.DailyStationInfo) (*GetStationScheduleResponse, []ParseWarning, error) {
+	p := &parser{logger: o.Logger}
 	response := &GetStationScheduleResponse{}
 	for i := range input {
-		response.Entries = append(response.Entries, ParseDailyStationInfo(&input[i]))
+		response.Entries = append(response.Entries, p.dailyStationInfo(&input[i], index("", i)))
 	}
-	return response, nil
+	return result(p, o, response)
 }
 
 func ParseDailyStationInfo(input *api.DailyStationInfo) StationSchedule {
+	return (&parser{}).dailyStationInfo(input, "")
+}
+
+func (p *parser) dailyStationInfo(input *api.DailyStationInfo, path string) StationSchedule {
 	stationSchedule := StationSchedule{
-		Station: strToStation(input.STATION_2CHAR, input.STATIONNAME),
+		Station: p.station(field(path, "STATION_2CHAR"), input.STATION_2CHAR, input.STATIONNAME),
 	}
 	for i := range input.ITEMS {
-		stationSchedule.Entries = append(stationSchedule.Entries, ParseDailyScheduleInfo(&input.ITEMS[i]))
+		stationSchedule.Entries = append(stationSchedule.Entries, p.dailyScheduleInfo(&input.ITEMS[i], index(field(path, "ITEMS"), i)))
 	}
 	return stationSchedule
 }
 
 func ParseDailyScheduleInfo(input *api.DailyScheduleInfo) ScheduleEntry {
+	return (&parser{}).dailyScheduleInfo(input, "")
+}
+
+func (p *parser) dailyScheduleInfo(input *api.DailyScheduleInfo, path string) ScheduleEntry {
 	destination := strUnquote(input.DESTINATION)
 	scheduleEntry := ScheduleEntry{
-		DepartureTime:      *strToLocalTime(input.SCHED_DEP_DATE, dateTimeFormat),
+		DepartureTime:      p.requiredLocalTime(field(path, "SCHED_DEP_DATE"), input.SCHED_DEP_DATE, dateTimeFormat),
 		Destination:        destination,
-		DestinationStation: strToStation("", destination),
-		Line:               strToLine("", input.LINE),
+		DestinationStation: p.station(field(path, "DESTINATION"), "", destination),
+		Line:               p.line(field(path, "LINE"), "", input.LINE),
 		TrainId:            input.TRAIN_ID,
 		ConnectingTrainId:  strToPtr(input.CONNECTING_TRAIN_ID),
 		StationPosition:    GetStationPosition(input.STATION_POSITION),
-		Direction:          strToDirection(input.DIRECTION),
-		DwellTime:          strToDurationSeconds(input.DWELL_TIME),
+		Direction:          p.direction(field(path, "DIRECTION"), input.DIRECTION),
+		DwellTime:          p.durationSeconds(field(path, "DWELL_TIME"), input.DWELL_TIME),
 		PickupOnly:         strToBool(input.PERM_PICKUP),
 		DropoffOnly:        strToBool(input.PERM_DROPOFF),
 		StopCode:           strToStopCode(input.STOP_CODE),
@@ -105,302 +170,502 @@ func ParseDailyScheduleInfo(input *api.DailyScheduleInfo) ScheduleEntry {
 }
 
 func ParseStationInfo(input *api.StationInfo) *GetTrainScheduleResponse {
+	response, _, _ := ParseOptions{}.ParseStationInfo(input)
+	return response
+}
+
+// ParseStationInfo parses the response of the getTrainSchedule and getTrainSchedule19Rec methods.
+// It returns the parsed response and a list of warnings.
+func (o ParseOptions) ParseStationInfo(input *api.StationInfo) (*GetTrainScheduleResponse, []ParseWarning, error) {
+	p := &parser{logger: o.Logger}
 	response := &GetTrainScheduleResponse{
-		Station: strToStation(input.STATION_2CHAR, input.STATIONNAME),
+		Station: p.station("STATION_2CHAR", input.STATION_2CHAR, input.STATIONNAME),
 	}
 	for i := range input.STATIONMSGS {
-		response.Messages = append(response.Messages, ParseStationMsgs(&input.STATIONMSGS[i]))
+		response.Messages = append(response.Messages, p.stationMsgs(&input.STATIONMSGS[i], index("STATIONMSGS", i)))
 	}
 	for i := range input.ITEMS {
-		response.Entries = append(response.Entries, ParseScheduleInfo(&input.ITEMS[i], &response.Station))
+		response.Entries = append(response.Entries, p.scheduleInfo(&input.ITEMS[i], &response.Station, index("ITEMS", i)))
 	}
-	return response
+	return result(p, o, response)
 }
 
 func ParseScheduleInfo(input *api.ScheduleInfo, station *Station) TrainScheduleEntry {
+	return (&parser{}).scheduleInfo(input, station, "")
+}
+
+func (p *parser) scheduleInfo(input *api.ScheduleInfo, station *Station, path string) TrainScheduleEntry {
 	destination := strUnquote(input.DESTINATION)
 	scheduleEntry := TrainScheduleEntry{
-		DepartureTime:     *strToLocalTime(input.SCHED_DEP_DATE, dateTimeFormat),
+		DepartureTime:     p.requiredLocalTime(field(path, "SCHED_DEP_DATE"), input.SCHED_DEP_DATE, dateTimeFormat),
 		Destination:       destination,
 		Track:             strToTrackName(input.TRACK, station),
-		Line:              strToLine(input.LINECODE, input.LINE),
+		Line:              p.line(field(path, "LINECODE"), input.LINECODE, input.LINE),
 		LineName:          input.LINE,
 		TrainId:           input.TRAIN_ID,
 		ConnectingTrainId: strToPtr(input.CONNECTING_TRAIN_ID),
 		Status:            strToPtr(input.STATUS),
-		Delay:             strToDurationSeconds(input.SEC_LATE),
-		LastUpdated:       strToLocalTime(input.LAST_MODIFIED, dateTimeFormat),
-		Color:             strsToColorSet(input.FORECOLOR, input.BACKCOLOR, input.SHADOWCOLOR),
-		GpsLocation:       strsToLocation(input.GPSLONGITUDE, input.GPSLATITUDE),
-		GpsTime:           strToLocalTime(input.GPSTIME, dateTimeFormat),
+		Delay:             p.durationSeconds(field(path, "SEC_LATE"), input.SEC_LATE),
+		LastUpdated:       p.localTime(field(path, "LAST_MODIFIED"), input.LAST_MODIFIED, dateTimeFormat),
+		Color:             p.colorSet(path, input.FORECOLOR, input.BACKCOLOR, input.SHADOWCOLOR),
+		GpsLocation:       p.location(path, "GPSLONGITUDE", input.GPSLONGITUDE, "GPSLATITUDE", input.GPSLATITUDE),
+		GpsTime:           p.localTime(field(path, "GPSTIME"), input.GPSTIME, dateTimeFormat),
 		StationPosition:   GetStationPosition(input.STATION_POSITION),
 		InlineMessage:     strToPtr(input.INLINEMSG),
 	}
 	for i := range input.CAPACITY {
-		scheduleEntry.Capacity = append(scheduleEntry.Capacity, ParseCapacityList(&input.CAPACITY[i]))
+		scheduleEntry.Capacity = append(scheduleEntry.Capacity, p.capacityList(&input.CAPACITY[i], index(field(path, "CAPACITY"), i)))
 	}
 	for i := range input.STOPS {
-		scheduleEntry.Stops = append(scheduleEntry.Stops, ParseStopList(&input.STOPS[i]))
+		scheduleEntry.Stops = append(scheduleEntry.Stops, p.stopList(&input.STOPS[i], index(field(path, "STOPS"), i)))
 	}
 	return scheduleEntry
 }
 
 func ParseCapacityList(input *api.CapacityList) TrainCapacity {
+	return (&parser{}).capacityList(input, "")
+}
+
+func (p *parser) capacityList(input *api.CapacityList, path string) TrainCapacity {
+	percent := p.optionalInt(field(path, "CUR_PERCENTAGE"), input.CUR_PERCENTAGE)
+	passengers := p.optionalInt(field(path, "CUR_PASSENGER_COUNT"), input.CUR_PASSENGER_COUNT)
 	response := TrainCapacity{
 		Number:          input.VEHICLE_NO,
-		Location:        *strsToLocation(input.LONGITUDE, input.LATITUDE),
-		CreatedTime:     *strToLocalTime(input.CREATED_TIME, dateTimeFormat),
+		CreatedTime:     p.requiredLocalTime(field(path, "CREATED_TIME"), input.CREATED_TIME, dateTimeFormat),
 		Type:            input.VEHICLE_TYPE,
-		CapacityPercent: strToInt(input.CUR_PERCENTAGE),
-		CapacityColor:   strToColor(input.CUR_CAPACITY_COLOR),
-		PassengerCount:  strToInt(input.CUR_PASSENGER_COUNT),
+		CapacityPercent: intOrZero(percent),
+		CapacityColor:   p.color(field(path, "CUR_CAPACITY_COLOR"), input.CUR_CAPACITY_COLOR),
+		PassengerCount:  intOrZero(passengers),
+
+		PreviousCapacityPercent: p.optionalInt(field(path, "PREV_PERCENTAGE"), input.PREV_PERCENTAGE),
+		PreviousCapacityColor:   p.optionalColor(field(path, "PREV_CAPACITY_COLOR"), input.PREV_CAPACITY_COLOR),
+		PreviousPassengerCount:  p.optionalInt(field(path, "PREV_PASSENGER_COUNT"), input.PREV_PASSENGER_COUNT),
+	}
+	response.Trend = capacityTrend(percent, passengers, response.PreviousCapacityPercent, response.PreviousPassengerCount)
+	if location := p.location(path, "LONGITUDE", input.LONGITUDE, "LATITUDE", input.LATITUDE); location != nil {
+		response.Location = *location
+	} else if strToPtr(input.LONGITUDE) == nil && strToPtr(input.LATITUDE) == nil {
+		p.warn(field(path, "LONGITUDE"), input.LONGITUDE, "missing value")
 	}
 	for i := range input.SECTIONS {
-		response.Sections = append(response.Sections, ParseSectionList(&input.SECTIONS[i]))
+		response.Sections = append(response.Sections, p.sectionList(&input.SECTIONS[i], index(field(path, "SECTIONS"), i)))
 	}
 	return response
 }
 
+// capacityTrend returns how the train's occupancy changed since the previous reading,
+// or nil if the current or the previous passenger count is not known.
+func capacityTrend(percent *int, passengers *int, prevPercent *int, prevPassengers *int) *CapacityTrend {
+	if passengers == nil || prevPassengers == nil {
+		return nil
+	}
+	trend := &CapacityTrend{PassengerDelta: *passengers - *prevPassengers}
+	if trend.PassengerDelta > 0 {
+		trend.Direction = TrendFilling
+	} else if trend.PassengerDelta < 0 {
+		trend.Direction = TrendEmptying
+	}
+	if percent != nil && prevPercent != nil {
+		delta := *percent - *prevPercent
+		trend.PercentDelta = &delta
+	}
+	return trend
+}
+
 func ParseSectionList(input *api.SectionList) TrainSection {
+	return (&parser{}).sectionList(input, "")
+}
+
+func (p *parser) sectionList(input *api.SectionList, path string) TrainSection {
 	response := TrainSection{
-		Position:        strToSectionPosition(input.SECTION_POSITION),
-		CapacityPercent: strToInt(input.CUR_PERCENTAGE),
-		CapacityColor:   strToColor(input.CUR_CAPACITY_COLOR),
-		PassengerCount:  strToInt(input.CUR_PASSENGER_COUNT),
+		Position:        p.sectionPosition(field(path, "SECTION_POSITION"), input.SECTION_POSITION),
+		CapacityPercent: p.int(field(path, "CUR_PERCENTAGE"), input.CUR_PERCENTAGE),
+		CapacityColor:   p.color(field(path, "CUR_CAPACITY_COLOR"), input.CUR_CAPACITY_COLOR),
+		PassengerCount:  p.int(field(path, "CUR_PASSENGER_COUNT"), input.CUR_PASSENGER_COUNT),
 	}
 	for i := range input.CARS {
-		response.Cars = append(response.Cars, ParseCarList(&input.CARS[i]))
+		response.Cars = append(response.Cars, p.carList(&input.CARS[i], index(field(path, "CARS"), i)))
 	}
 	return response
 }
 
 func ParseCarList(input *api.CarList) TrainCar {
+	return (&parser{}).carList(input, "")
+}
+
+func (p *parser) carList(input *api.CarList, path string) TrainCar {
 	response := TrainCar{
 		TrainId:         input.CAR_NO,
-		Position:        strToInt(input.CAR_POSITION),
+		Position:        p.int(field(path, "CAR_POSITION"), input.CAR_POSITION),
 		Restroom:        input.CAR_REST,
-		CapacityPercent: strToInt(input.CUR_PERCENTAGE),
-		CapacityColor:   strToColor(input.CUR_CAPACITY_COLOR),
-		PassengerCount:  strToInt(input.CUR_PASSENGER_COUNT),
+		CapacityPercent: p.int(field(path, "CUR_PERCENTAGE"), input.CUR_PERCENTAGE),
+		CapacityColor:   p.color(field(path, "CUR_CAPACITY_COLOR"), input.CUR_CAPACITY_COLOR),
+		PassengerCount:  p.int(field(path, "CUR_PASSENGER_COUNT"), input.CUR_PASSENGER_COUNT),
 	}
 	return response
 }
 
 func ParseStopList(input *api.StopList) TrainStop {
+	return (&parser{}).stopList(input, "")
+}
+
+func (p *parser) stopList(input *api.StopList, path string) TrainStop {
 	response := TrainStop{
-		Station:       strToStation(input.STATION_2CHAR, input.STATIONNAME),
-		ArrivalTime:   strToLocalTime(input.TIME, dateTimeFormat),
+		Station:       p.station(field(path, "STATION_2CHAR"), input.STATION_2CHAR, input.STATIONNAME),
+		ArrivalTime:   p.localTime(field(path, "TIME"), input.TIME, dateTimeFormat),
 		PickupOnly:    strToBool(input.PICKUP),
 		DropoffOnly:   strToBool(input.DROPOFF),
 		Departed:      strToBool(input.DEPARTED),
 		StopStatus:    strToPtr(input.STOP_STATUS),
-		DepartureTime: strToLocalTime(input.DEP_TIME, dateTimeFormat),
+		DepartureTime: p.localTime(field(path, "DEP_TIME"), input.DEP_TIME, dateTimeFormat),
 	}
 	for i := range input.STOP_LINES {
-		response.StopLines = append(response.StopLines, ParseStopLines(&input.STOP_LINES[i]))
+		response.StopLines = append(response.StopLines, p.stopLines(&input.STOP_LINES[i], index(field(path, "STOP_LINES"), i)))
 	}
 	return response
 }
 
 func ParseStopLines(input *api.StopLines) StopLine {
+	return (&parser{}).stopLines(input, "")
+}
+
+func (p *parser) stopLines(input *api.StopLines, path string) StopLine {
 	response := StopLine{
-		Line:  strToLine(input.LINE_CODE, input.LINE_NAME),
-		Color: strToColor(input.LINE_COLOR),
+		Line:  p.line(field(path, "LINE_CODE"), input.LINE_CODE, input.LINE_NAME),
+		Color: p.color(field(path, "LINE_COLOR"), input.LINE_COLOR),
 	}
 	return response
 }
 
 func ParseStops(input *api.Stops) *GetTrainStopListResponse {
+	response, _, _ := ParseOptions{}.ParseStops(input)
+	return response
+}
+
+// ParseStops parses the response of the getTrainStopList method. It returns the parsed response and a list of warnings.
+// The response is nil if the train was not found.
+func (o ParseOptions) ParseStops(input *api.Stops) (*GetTrainStopListResponse, []ParseWarning, error) {
 	trainidp := strToPtr(input.TRAIN_ID)
 	if trainidp == nil {
-		return nil
+		return nil, nil, nil
 	}
+	p := &parser{logger: o.Logger}
 	destination := strUnquote(input.DESTINATION)
 	response := &GetTrainStopListResponse{
 		TrainId:            *trainidp,
-		Line:               strToLine(input.LINECODE, ""),
-		Color:              strsToColorSet(input.FORECOLOR, input.BACKCOLOR, input.SHADOWCOLOR),
+		Line:               p.line("LINECODE", input.LINECODE, ""),
+		Color:              p.colorSet("", input.FORECOLOR, input.BACKCOLOR, input.SHADOWCOLOR),
 		Destination:        destination,
-		DestinationStation: strToStation("", destination),
+		DestinationStation: p.station("DESTINATION", "", destination),
 		TransferAt:         strToPtr(input.TRANSFERAT),
 	}
 	for i := range input.STOPS {
-		response.Stops = append(response.Stops, ParseStopList(&input.STOPS[i]))
+		response.Stops = append(response.Stops, p.stopList(&input.STOPS[i], index("STOPS", i)))
 	}
 	for i := range input.CAPACITY {
-		response.Capacity = append(response.Capacity, ParseCapacityList(&input.CAPACITY[i]))
+		response.Capacity = append(response.Capacity, p.capacityList(&input.CAPACITY[i], index("CAPACITY", i)))
 	}
-	return response
+	return result(p, o, response)
 }
 
 func ParseVehicleDataInfoList(input []api.VehicleDataInfo) *GetVehicleDataResponse {
+	response, _, _ := ParseOptions{}.ParseVehicleDataInfoList(input)
+	return response
+}
+
+// ParseVehicleDataInfoList parses the response of the getVehicleData method. It returns the parsed response and a list of warnings.
+func (o ParseOptions) ParseVehicleDataInfoList(input []api.VehicleDataInfo) (*GetVehicleDataResponse, []ParseWarning, error) {
+	p := &parser{logger: o.Logger}
 	response := &GetVehicleDataResponse{}
 	for i := range input {
-		response.Vehicles = append(response.Vehicles, *ParseVehicleDataInfo(&input[i]))
+		response.Vehicles = append(response.Vehicles, *p.vehicleDataInfo(&input[i], index("", i)))
 	}
-	return response
+	return result(p, o, response)
 }
 
 func ParseVehicleDataInfo(input *api.VehicleDataInfo) *VehicleData {
+	return (&parser{}).vehicleDataInfo(input, "")
+}
+
+func (p *parser) vehicleDataInfo(input *api.VehicleDataInfo, path string) *VehicleData {
 	response := &VehicleData{
 		TrainId:        input.ID,
-		Line:           strToLine("", input.TRAIN_LINE),
-		Direction:      strToDirection(input.DIRECTION),
+		Line:           p.line(field(path, "TRAIN_LINE"), "", input.TRAIN_LINE),
+		Direction:      p.direction(field(path, "DIRECTION"), input.DIRECTION),
 		TrackCircuitId: input.ICS_TRACK_CKT,
-		LastUpdated:    *strToLocalTime(input.LAST_MODIFIED, dateTimeFormat),
-		DepartureTime:  *strToLocalTime(input.SCHED_DEP_TIME, dateTimeFormat),
-		Delay:          strToDurationSeconds(input.SEC_LATE),
-		NextStop:       strToStation("", input.NEXT_STOP),
-		Location:       strsToLocation(input.LONGITUDE, input.LATITUDE),
+		LastUpdated:    p.requiredLocalTime(field(path, "LAST_MODIFIED"), input.LAST_MODIFIED, dateTimeFormat),
+		DepartureTime:  p.requiredLocalTime(field(path, "SCHED_DEP_TIME"), input.SCHED_DEP_TIME, dateTimeFormat),
+		Delay:          p.durationSeconds(field(path, "SEC_LATE"), input.SEC_LATE),
+		NextStop:       p.station(field(path, "NEXT_STOP"), "", input.NEXT_STOP),
+		Location:       p.location(path, "LONGITUDE", input.LONGITUDE, "LATITUDE", input.LATITUDE),
 	}
 	return response
 }
 
-func strUnquote(s string) string {
-	return html.UnescapeString(s)
+// parser collects the warnings produced while parsing a response.
+type parser struct {
+	logger   *slog.Logger
+	warnings []ParseWarning
 }
 
-func strToPtr(s string) *string {
-	s = strings.TrimSpace(s)
-	if len(s) == 0 {
-		return nil
+// fallback logs that a value was made up because it couldn't be found.
+func (p *parser) fallback(msg string, field string, code string, name string) {
+	if p.logger != nil {
+		p.logger.Debug(msg, "field", field, "code", code, "name", name)
 	}
-	return &s
 }
 
-func strToBool(s string) bool {
-	s = strings.ToLower(s)
-	return s == "true" || s == "yes"
+func (p *parser) warn(field string, value string, reason string) {
+	p.warnings = append(p.warnings, ParseWarning{Field: field, Value: value, Reason: reason})
 }
 
-func strToFloat(s string) *float64 {
-	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return nil
+// result returns the response and the warnings, or an error if there were warnings in strict mode.
+func result[T any](p *parser, o ParseOptions, response *T) (*T, []ParseWarning, error) {
+	if o.Strict && len(p.warnings) > 0 {
+		w := p.warnings[0]
+		return nil, p.warnings, rderrors.NewParseError(w.Field, w.Value, w.Reason)
 	}
-	return &f
+	return response, p.warnings, nil
+}
+
+func field(path string, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
+
+func index(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
 }
 
-func strToInt(s string) int {
-	i, err := strconv.ParseInt(s, 10, 64)
+func (p *parser) int(field string, s string) int {
+	if strToPtr(s) == nil {
+		return 0
+	}
+	i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
 	if err != nil {
+		p.warn(field, s, "not an integer")
 		return 0
 	}
 	return int(i)
 }
 
-func strToColor(s string) Color {
-	p := strToPtr(s)
-	if p == nil {
+func (p *parser) optionalInt(field string, s string) *int {
+	if strToPtr(s) == nil {
+		return nil
+	}
+	i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		p.warn(field, s, "not an integer")
+		return nil
+	}
+	out := int(i)
+	return &out
+}
+
+func (p *parser) optionalColor(field string, s string) *Color {
+	if strToPtr(s) == nil {
+		return nil
+	}
+	color := p.color(field, s)
+	return &color
+}
+
+func (p *parser) color(field string, s string) Color {
+	c := strToPtr(s)
+	if c == nil {
 		return Color{}
 	}
-	c, err := ParseHtmlColor(*p)
+	color, err := ParseHtmlColor(*c)
 	if err != nil {
+		p.warn(field, s, "not a color")
 		return Color{}
 	}
-	return c
+	return color
 }
 
-func strsToColorSet(fg, bg, shadow string) ColorSet {
-	fgc := strToColor(fg)
-	bgc := strToColor(bg)
-	shadowc := strToColor(shadow)
+func (p *parser) colorSet(path string, fg, bg, shadow string) ColorSet {
 	return ColorSet{
-		Foreground: fgc,
-		Background: bgc,
-		Shadow:     shadowc,
+		Foreground: p.color(field(path, "FORECOLOR"), fg),
+		Background: p.color(field(path, "BACKCOLOR"), bg),
+		Shadow:     p.color(field(path, "SHADOWCOLOR"), shadow),
 	}
 }
 
-func strToLocalTime(s string, format string) *time.Time {
+func (p *parser) localTime(field string, s string, format string) *time.Time {
+	if strToPtr(s) == nil {
+		return nil
+	}
 	t, err := time.ParseInLocation(format, s, njLocation)
 	if err != nil {
+		p.warn(field, s, "not a date/time in the format "+format)
 		return nil
 	}
 	return &t
 }
 
-func strToDurationSeconds(s string) *time.Duration {
-	secs, err := strconv.ParseInt(s, 10, 64)
+// requiredLocalTime parses a date/time that should always be present. It returns the zero time if it can't.
+func (p *parser) requiredLocalTime(field string, s string, format string) time.Time {
+	if strToPtr(s) == nil {
+		p.warn(field, s, "missing value")
+		return time.Time{}
+	}
+	t := p.localTime(field, s, format)
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+func (p *parser) durationSeconds(field string, s string) *time.Duration {
+	if strToPtr(s) == nil {
+		return nil
+	}
+	secs, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
 	if err != nil {
+		p.warn(field, s, "not a number of seconds")
 		return nil
 	}
 	duration := time.Duration(secs) * time.Second
 	return &duration
 }
 
-func strsToLocation(lon string, lat string) *Location {
-	lonf := strToFloat(lon)
-	latf := strToFloat(lat)
-	if lonf == nil || latf == nil {
+func (p *parser) float(field string, s string) *float64 {
+	if strToPtr(s) == nil {
 		return nil
 	}
-	return &Location{
-		Longitude: *lonf,
-		Latitude:  *latf,
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		p.warn(field, s, "not a number")
+		return nil
 	}
+	return &f
 }
 
-func strToStation(code string, name string) Station {
-	fs := FindStation()
-	if codep := (*StationCode)(strToPtr(code)); codep != nil {
-		fs = fs.WithCode(*codep)
-	}
-	if namep := strToPtr(name); namep != nil {
-		fs = fs.WithName(*namep)
+func (p *parser) location(path string, lonField string, lon string, latField string, lat string) *Location {
+	lonf := p.float(field(path, lonField), lon)
+	latf := p.float(field(path, latField), lat)
+	if lonf == nil && latf == nil {
+		return nil
 	}
-	return fs.SearchOrSynthesize()
-}
-
-func strToLine(code string, name string) Line {
-	fs := FindLine()
-	if codep := (*LineCode)(strToPtr(code)); codep != nil {
-		fs = fs.WithCode(*codep)
+	if lonf == nil {
+		p.warn(field(path, lonField), lon, "longitude missing or invalid while latitude is present")
+		return nil
 	}
-	if namep := strToPtr(name); namep != nil {
-		fs = fs.WithName(*namep)
+	if latf == nil {
+		p.warn(field(path, latField), lat, "latitude missing or invalid while longitude is present")
+		return nil
 	}
-	return fs.SearchOrSynthesize()
-}
-
-func strToTrackName(track string, station *Station) *string {
-	trackp := strToPtr(track)
-	if trackp == nil || station == nil {
-		return trackp
+	return &Location{
+		Longitude: *lonf,
+		Latitude:  *latf,
 	}
-	translation := TranslateTrackNumber(track, station.Code)
-	return &translation
 }
 
-func strToMsgType(msgType string) MsgType {
+func (p *parser) msgType(field string, msgType string) MsgType {
 	switch msgType {
 	case "fullscreen":
 		return MsgTypeFullScreen
+	case "banner", "":
+		return MsgTypeBanner
 	default:
+		p.warn(field, msgType, "unknown message type")
 		return MsgTypeBanner
 	}
 }
 
-func strToDirection(direction string) Direction {
+func (p *parser) direction(field string, direction string) Direction {
 	switch direction {
 	case "Eastbound":
 		return DirectionEastbound
+	case "Westbound", "":
+		return DirectionWestbound
 	default:
+		p.warn(field, direction, "unknown direction")
 		return DirectionWestbound
 	}
 }
 
-func strToSectionPosition(position string) SectionPosition {
+func (p *parser) sectionPosition(field string, position string) SectionPosition {
 	switch position {
 	case "Front":
 		return SectionPositionFront
 	case "Back":
 		return SectionPositionBack
+	case "Middle", "":
+		return SectionPositionMiddle
 	default:
+		p.warn(field, position, "unknown section position")
 		return SectionPositionMiddle
 	}
 }
 
+func strUnquote(s string) string {
+	return html.UnescapeString(s)
+}
+
+func intOrZero(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+func strToPtr(s string) *string {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 {
+		return nil
+	}
+	return &s
+}
+
+func strToBool(s string) bool {
+	s = strings.ToLower(s)
+	return s == "true" || s == "yes"
+}
+
+func (p *parser) station(field string, code string, name string) Station {
+	fs := FindStation()
+	if codep := (*StationCode)(strToPtr(code)); codep != nil {
+		fs = fs.WithCode(*codep)
+	}
+	if namep := strToPtr(name); namep != nil {
+		fs = fs.WithName(*namep)
+	}
+	if station, found := fs.Search(); found {
+		return *station
+	}
+	station := fs.SearchOrSynthesize()
+	p.fallback("synthesized station", field, string(station.Code), station.Name)
+	return station
+}
+
+func (p *parser) line(field string, code string, name string) Line {
+	fs := FindLine()
+	if codep := (*LineCode)(strToPtr(code)); codep != nil {
+		fs = fs.WithCode(*codep)
+	}
+	if namep := strToPtr(name); namep != nil {
+		fs = fs.WithName(*namep)
+	}
+	if line, found := fs.Search(); found {
+		return *line
+	}
+	line := fs.SearchOrSynthesize()
+	p.fallback("synthesized line", field, string(line.Code), line.Name)
+	return line
+}
+
+func strToTrackName(track string, station *Station) *string {
+	trackp := strToPtr(track)
+	if trackp == nil || station == nil {
+		return trackp
+	}
+	translation := TranslateTrackNumber(track, station.Code)
+	return &translation
+}
+
 func strToStopCode(code string) *StopCode {
 	codep := strToPtr(code)
 	if codep == nil {
@@ -410,20 +675,20 @@ func strToStopCode(code string) *StopCode {
 	return &stopCode
 }
 
-func decodeStationScope(s string) []Station {
+func (p *parser) stationScope(field string, s string) []Station {
 	scope := decodeScope(s)
 	var out []Station
 	for _, stationName := range scope {
-		out = append(out, FindStation().WithName(stationName).SearchOrSynthesize())
+		out = append(out, p.station(field, "", stationName))
 	}
 	return out
 }
 
-func decodeLineScope(s string) []Line {
+func (p *parser) lineScope(field string, s string) []Line {
 	scope := decodeScope(s)
 	var out []Line
 	for _, lineName := range scope {
-		out = append(out, FindLine().WithName(lineName).SearchOrSynthesize())
+		out = append(out, p.line(field, "", lineName))
 	}
 	return out
 }
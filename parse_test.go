@@ -0,0 +1,155 @@
+package raildata_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/api"
+	"github.com/jtarrio/raildata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const badVehicleData = `[
+  {
+    "ID": "3887",
+    "TRAIN_LINE": "Northeast Corridor Line",
+    "DIRECTION": "Northbound",
+    "ICS_TRACK_CKT": "",
+    "LAST_MODIFIED": "yesterday",
+    "SCHED_DEP_TIME": "",
+    "SEC_LATE": "late",
+    "NEXT_STOP": "New York Penn Station",
+    "LONGITUDE": "-74.5",
+    "LATITUDE": ""
+  }
+]`
+
+func TestLenientParsingReportsWarnings(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getVehicleData").sendJson(badVehicleData))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken))
+	require.NoError(t, err)
+
+	actual, err := client.GetVehicleData(context.Background())
+	require.NoError(t, err)
+	require.Len(t, actual.Vehicles, 1)
+	assert.Equal(t, "3887", actual.Vehicles[0].TrainId)
+	assert.True(t, actual.Vehicles[0].LastUpdated.IsZero())
+	assert.Nil(t, actual.Vehicles[0].Delay)
+	assert.Nil(t, actual.Vehicles[0].Location)
+	assert.Equal(t, []raildata.ParseWarning{
+		{Field: "[0].DIRECTION", Value: "Northbound", Reason: "unknown direction"},
+		{Field: "[0].LAST_MODIFIED", Value: "yesterday", Reason: "not a date/time in the format 02-Jan-2006 03:04:05 PM"},
+		{Field: "[0].SCHED_DEP_TIME", Value: "", Reason: "missing value"},
+		{Field: "[0].SEC_LATE", Value: "late", Reason: "not a number of seconds"},
+		{Field: "[0].LATITUDE", Value: "", Reason: "latitude missing or invalid while longitude is present"},
+	}, actual.Info.Warnings)
+}
+
+func TestStrictParsingFails(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getVehicleData").sendJson(badVehicleData))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithParseOptions(raildata.ParseOptions{Strict: true}))
+	require.NoError(t, err)
+
+	_, err = client.GetVehicleData(context.Background())
+	var perr *errors.ParseError
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, "[0].DIRECTION", perr.Field)
+	assert.Equal(t, "Northbound", perr.Value)
+}
+
+func TestParseBadDatesDoesNotPanic(t *testing.T) {
+	msgs := []api.StationMsgs{{MSG_TYPE: "banner", MSG_TEXT: "Text", MSG_PUBDATE: "not a date"}}
+	assert.NotPanics(t, func() { raildata.ParseStationMsgsList(msgs) })
+
+	schedule := []api.DailyStationInfo{{STATION_2CHAR: "NY", ITEMS: []api.DailyScheduleInfo{{SCHED_DEP_DATE: ""}}}}
+	assert.NotPanics(t, func() { _, _ = raildata.ParseDailyStationInfoList(schedule) })
+
+	_, warnings, err := raildata.ParseOptions{}.ParseDailyStationInfoList(schedule)
+	assert.NoError(t, err)
+	assert.Equal(t, []raildata.ParseWarning{{Field: "[0].ITEMS[0].SCHED_DEP_DATE", Value: "", Reason: "missing value"}}, warnings)
+
+	response, _, err := raildata.ParseOptions{Strict: true}.ParseDailyStationInfoList(schedule)
+	assert.Nil(t, response)
+	var perr *errors.ParseError
+	assert.ErrorAs(t, err, &perr)
+}
+
+func TestParseNestedFieldPaths(t *testing.T) {
+	info := &api.StationInfo{
+		STATION_2CHAR: "NY",
+		ITEMS: []api.ScheduleInfo{{
+			SCHED_DEP_DATE: "17-Jan-2025 02:40:00 PM",
+			BACKCOLOR:      "blue",
+			CAPACITY: []api.CapacityList{{
+				LONGITUDE:      "-74.5",
+				LATITUDE:       "40.1",
+				CREATED_TIME:   "17-Jan-2025 02:40:00 PM",
+				CUR_PERCENTAGE: "half",
+			}},
+			STOPS: []api.StopList{{TIME: "17-Jan-2025 02:40:00 PM"}, {TIME: "14:40"}},
+		}},
+	}
+	response, warnings, err := raildata.ParseOptions{}.ParseStationInfo(info)
+	require.NoError(t, err)
+	assert.Equal(t, time.January, response.Entries[0].DepartureTime.Month())
+	assert.Equal(t, []raildata.ParseWarning{
+		{Field: "ITEMS[0].BACKCOLOR", Value: "blue", Reason: "not a color"},
+		{Field: "ITEMS[0].CAPACITY[0].CUR_PERCENTAGE", Value: "half", Reason: "not an integer"},
+		{Field: "ITEMS[0].STOPS[1].TIME", Value: "14:40", Reason: "not a date/time in the format 02-Jan-2006 03:04:05 PM"},
+	}, warnings)
+}
+
+func TestParseCapacityTrend(t *testing.T) {
+	capacity := raildata.ParseCapacityList(&api.CapacityList{
+		LONGITUDE:            "-74.5",
+		LATITUDE:             "40.1",
+		CREATED_TIME:         "17-Jan-2025 02:40:00 PM",
+		CUR_PERCENTAGE:       "40",
+		CUR_PASSENGER_COUNT:  "120",
+		PREV_PERCENTAGE:      "25",
+		PREV_PASSENGER_COUNT: "75",
+	})
+	require.NotNil(t, capacity.Trend)
+	assert.Equal(t, raildata.TrendFilling, capacity.Trend.Direction)
+	assert.Equal(t, 45, capacity.Trend.PassengerDelta)
+	assert.Equal(t, ptr(15), capacity.Trend.PercentDelta)
+	assert.Nil(t, capacity.PreviousCapacityColor)
+
+	capacity = raildata.ParseCapacityList(&api.CapacityList{
+		LONGITUDE:            "-74.5",
+		LATITUDE:             "40.1",
+		CREATED_TIME:         "17-Jan-2025 02:40:00 PM",
+		CUR_PASSENGER_COUNT:  "20",
+		PREV_PERCENTAGE:      "25",
+		PREV_PASSENGER_COUNT: "75",
+	})
+	require.NotNil(t, capacity.Trend)
+	assert.Equal(t, raildata.TrendEmptying, capacity.Trend.Direction)
+	assert.Equal(t, -55, capacity.Trend.PassengerDelta)
+	assert.Nil(t, capacity.Trend.PercentDelta)
+
+	capacity = raildata.ParseCapacityList(&api.CapacityList{
+		LONGITUDE:           "-74.5",
+		LATITUDE:            "40.1",
+		CREATED_TIME:        "17-Jan-2025 02:40:00 PM",
+		CUR_PASSENGER_COUNT: "20",
+	})
+	assert.Nil(t, capacity.Trend)
+
+	capacity = raildata.ParseCapacityList(&api.CapacityList{
+		LONGITUDE:            "-74.5",
+		LATITUDE:             "40.1",
+		CREATED_TIME:         "17-Jan-2025 02:40:00 PM",
+		CUR_PERCENTAGE:       "40",
+		PREV_PERCENTAGE:      "25",
+		PREV_PASSENGER_COUNT: "75",
+	})
+	assert.Zero(t, capacity.PassengerCount)
+	assert.Nil(t, capacity.Trend)
+}
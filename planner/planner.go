@@ -0,0 +1,577 @@
+// Package planner computes itineraries between NJ Transit rail stations, including transfers between lines.
+//
+// The planner builds a timetable from the station schedules returned by GetStationSchedule and adjusts it with
+// the live delays and cancellations returned by GetTrainSchedule. It can only use the stations whose schedules
+// it has loaded, so [Planner.Plan] uses the line topology to find the stations where the passenger may need to change
+// trains, and only loads the schedules for those stations, the origin, and the destination.
+//
+// GetStationSchedule is one of the [raildata.RateLimitedMethods], and planning a trip with two transfers needs
+// the schedules of four stations, so the planner keeps every schedule it loads and only retrieves it again when
+// it is older than the schedule TTL. If a schedule can't be retrieved, the planner waits for the retry interval
+// before trying again. You should also give the client a cache that survives restarts, for example one created
+// with [raildata.NewFileCache], so the schedules are shared with other processes and with the departures package.
+// If you already have the schedules, you can add them with [Planner.AddStationSchedule] instead.
+//
+// Example:
+//
+//	p := planner.New(client)
+//	trips, err := p.Plan(ctx, "UV", "PR", time.Now())
+package planner
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jtarrio/raildata"
+)
+
+// DefaultMinTransferTime contains the default minimum time needed to change trains within a station.
+const DefaultMinTransferTime = 5 * time.Minute
+
+// DefaultScheduleTTL contains the default time a station's schedule is kept before retrieving it again.
+const DefaultScheduleTTL = 24 * time.Hour
+
+// DefaultRetryInterval contains the default time to wait after failing to retrieve a station's schedule
+// before trying again.
+const DefaultRetryInterval = time.Hour
+
+// DefaultMaxTrips contains the default maximum number of trips returned by [Planner.Plan].
+const DefaultMaxTrips = 5
+
+// DefaultWalkingTransfers contains the default list of transfers between stations that have different codes
+// but are part of the same complex, like the upper (SE) and lower (TS) levels at Secaucus Junction.
+var DefaultWalkingTransfers = []WalkingTransfer{
+	{From: "SE", To: "TS", Time: 10 * time.Minute},
+	{From: "TS", To: "SE", Time: 10 * time.Minute},
+}
+
+// maxRunGap contains the longest interval between two consecutive stops of a train. Train numbers are reused
+// every day, so schedule entries with the same train id that are further apart belong to different runs.
+const maxRunGap = 4 * time.Hour
+
+// WalkingTransfer contains the time needed to walk from one station to another to change trains.
+type WalkingTransfer struct {
+	// From contains the station where the passenger leaves the train.
+	From raildata.StationCode
+	// To contains the station where the passenger boards the next train.
+	To raildata.StationCode
+	// Time contains the minimum time needed to make the transfer.
+	Time time.Duration
+}
+
+// Trip contains an itinerary from one station to another.
+type Trip struct {
+	// Departure contains the date/time the first train leaves the origin.
+	Departure time.Time
+	// Arrival contains the date/time the last train arrives at the destination.
+	Arrival time.Time
+	// Legs contains the trains the passenger needs to take, in order.
+	Legs []Leg
+}
+
+// Duration returns the time between the trip's departure and arrival.
+func (t *Trip) Duration() time.Duration {
+	return t.Arrival.Sub(t.Departure)
+}
+
+// Transfers returns the number of times the passenger needs to change trains.
+func (t *Trip) Transfers() int {
+	return max(0, len(t.Legs)-1)
+}
+
+// TransferWait returns the total time the passenger spends waiting between trains.
+func (t *Trip) TransferWait() time.Duration {
+	var wait time.Duration
+	for _, leg := range t.Legs {
+		wait += leg.Wait
+	}
+	return wait
+}
+
+// TrainIds returns the ids of the trains the passenger needs to take, in order.
+func (t *Trip) TrainIds() []string {
+	var out []string
+	for _, leg := range t.Legs {
+		out = append(out, leg.TrainId)
+	}
+	return out
+}
+
+// Leg contains a part of a trip that is made on a single train.
+type Leg struct {
+	// TrainId contains the train's number.
+	TrainId string
+	// Line contains the line the train runs on.
+	Line raildata.Line
+	// From contains the station where the passenger boards the train.
+	From raildata.Station
+	// To contains the station where the passenger leaves the train.
+	To raildata.Station
+	// Departure contains the expected departure date/time from the From station, including any known delay.
+	Departure time.Time
+	// Arrival contains the expected arrival date/time at the To station, including any known delay.
+	Arrival time.Time
+	// Delay contains the train's known delay.
+	Delay time.Duration
+	// Wait contains the time between the arrival of the previous leg and the departure of this leg.
+	// It is zero for the first leg.
+	Wait time.Duration
+}
+
+// Option is the type of the options that can be passed to [New].
+type Option func(*Planner)
+
+// WithMinTransferTime sets the minimum time needed to change trains within a station.
+// The default is [DefaultMinTransferTime].
+func WithMinTransferTime(d time.Duration) Option {
+	return func(p *Planner) {
+		p.minTransfer = d
+	}
+}
+
+// WithWalkingTransfers sets the list of transfers between stations with different codes.
+// The default is [DefaultWalkingTransfers].
+func WithWalkingTransfers(transfers []WalkingTransfer) Option {
+	return func(p *Planner) {
+		p.walks = map[raildata.StationCode][]WalkingTransfer{}
+		for _, transfer := range transfers {
+			p.walks[transfer.From] = append(p.walks[transfer.From], transfer)
+		}
+	}
+}
+
+// WithMaxTrips sets the maximum number of trips returned by [Planner.Plan] and [Planner.Search].
+// If n is zero or negative, all the trips found in the timetable are returned.
+// The default is [DefaultMaxTrips].
+func WithMaxTrips(n int) Option {
+	return func(p *Planner) {
+		p.maxTrips = n
+	}
+}
+
+// WithScheduleTTL sets how long a station's schedule is kept before [Planner.Prepare] retrieves it again.
+// The default is [DefaultScheduleTTL].
+func WithScheduleTTL(ttl time.Duration) Option {
+	return func(p *Planner) {
+		p.scheduleTTL = ttl
+	}
+}
+
+// WithRetryInterval sets how long [Planner.Prepare] waits after failing to retrieve a station's schedule
+// before trying again. The default is [DefaultRetryInterval].
+func WithRetryInterval(interval time.Duration) Option {
+	return func(p *Planner) {
+		p.retryInterval = interval
+	}
+}
+
+// WithClock sets the function that the planner uses to get the current time.
+func WithClock(now func() time.Time) Option {
+	return func(p *Planner) {
+		p.now = now
+	}
+}
+
+// Planner computes itineraries using the schedules for a set of stations.
+//
+// A Planner is safe for concurrent use.
+type Planner struct {
+	client        raildata.Client
+	minTransfer   time.Duration
+	walks         map[raildata.StationCode][]WalkingTransfer
+	maxTrips      int
+	scheduleTTL   time.Duration
+	retryInterval time.Duration
+	now           func() time.Time
+
+	mutex    sync.Mutex
+	stations map[raildata.StationCode]raildata.Station
+	runs     map[string][]*run
+	live     map[string][]liveStatus
+	// fetched contains the time each station's schedule was retrieved with [Planner.Load].
+	fetched map[raildata.StationCode]time.Time
+	// failed contains the time of the last failed attempt to retrieve each station's schedule.
+	failed map[raildata.StationCode]time.Time
+}
+
+// run contains the stops of one train on one day, sorted by time.
+type run struct {
+	trainId string
+	line    raildata.Line
+	stops   []stop
+}
+
+type stop struct {
+	station   raildata.Station
+	arrival   time.Time
+	departure time.Time
+	canBoard  bool
+	canAlight bool
+}
+
+// liveStatus contains the realtime information about a train, as seen from a station.
+type liveStatus struct {
+	station   raildata.StationCode
+	departure time.Time
+	delay     time.Duration
+	cancelled bool
+}
+
+// New creates a [Planner] that gets its schedules and delays from the given client.
+// The client may be nil if you only use [Planner.AddStationSchedule], [Planner.AddTrainSchedule] and [Planner.Search].
+func New(client raildata.Client, options ...Option) *Planner {
+	p := &Planner{
+		client:        client,
+		minTransfer:   DefaultMinTransferTime,
+		maxTrips:      DefaultMaxTrips,
+		scheduleTTL:   DefaultScheduleTTL,
+		retryInterval: DefaultRetryInterval,
+		now:           time.Now,
+		stations:      map[raildata.StationCode]raildata.Station{},
+		runs:          map[string][]*run{},
+		live:          map[string][]liveStatus{},
+		fetched:       map[raildata.StationCode]time.Time{},
+		failed:        map[raildata.StationCode]time.Time{},
+	}
+	WithWalkingTransfers(DefaultWalkingTransfers)(p)
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
+}
+
+// Load retrieves the schedules for the given stations with GetStationSchedule and adds them to the timetable,
+// even if they were already loaded. Only NJ Transit trains are loaded.
+//
+// Every station costs one call to GetStationSchedule, which is one of the [raildata.RateLimitedMethods].
+func (p *Planner) Load(ctx context.Context, stations ...raildata.StationCode) error {
+	for _, station := range stations {
+		if err := p.load(ctx, station); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Planner) load(ctx context.Context, station raildata.StationCode) error {
+	now := p.now()
+	response, err := p.client.RateLimitedMethods().GetStationSchedule(ctx, &raildata.GetStationScheduleRequest{StationCode: station, NjtOnly: true})
+	p.mutex.Lock()
+	if err != nil {
+		// A cancelled caller doesn't mean that the schedule is unavailable.
+		if ctx.Err() == nil {
+			p.failed[station] = now
+		}
+		p.mutex.Unlock()
+		return err
+	}
+	p.fetched[station] = now
+	delete(p.failed, station)
+	p.mutex.Unlock()
+	for _, schedule := range response.Entries {
+		p.AddStationSchedule(schedule)
+	}
+	return nil
+}
+
+// UpdateDelays retrieves the live status of the trains departing from every loaded station with GetTrainSchedule
+// and applies their delays and cancellations to the timetable.
+//
+// Every loaded station costs one call to GetTrainSchedule, so the cost of this method grows with the number of
+// stations the planner has loaded.
+func (p *Planner) UpdateDelays(ctx context.Context) error {
+	for _, station := range p.Stations() {
+		response, err := p.client.GetTrainSchedule(ctx, &raildata.GetTrainScheduleRequest{StationCode: station})
+		if err != nil {
+			return err
+		}
+		p.AddTrainSchedule(response)
+	}
+	return nil
+}
+
+// Plan loads the schedules needed to travel between two stations, as described in [Planner.Prepare], updates the
+// live delays with [Planner.UpdateDelays], and returns the best trips from one station to another that depart
+// at or after the given time. See [Planner.Search] for details.
+func (p *Planner) Plan(ctx context.Context, from raildata.StationCode, to raildata.StationCode, departAfter time.Time) ([]Trip, error) {
+	if err := p.Prepare(ctx, from, to); err != nil {
+		return nil, err
+	}
+	if err := p.UpdateDelays(ctx); err != nil {
+		return nil, err
+	}
+	return p.Search(from, to, departAfter)
+}
+
+// Stations returns the codes of the stations whose schedules have been loaded.
+func (p *Planner) Stations() []raildata.StationCode {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return slices.Sorted(maps.Keys(p.stations))
+}
+
+// AddStationSchedule adds a station's schedule to the timetable.
+func (p *Planner) AddStationSchedule(schedule raildata.StationSchedule) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.stations[schedule.Station.Code] = schedule.Station
+	for i := range schedule.Entries {
+		entry := &schedule.Entries[i]
+		arrival := entry.DepartureTime
+		if entry.DwellTime != nil {
+			arrival = arrival.Add(-*entry.DwellTime)
+		}
+		p.addStop(entry.TrainId, entry.Line, stop{
+			station:   schedule.Station,
+			arrival:   arrival,
+			departure: entry.DepartureTime,
+			canBoard:  !entry.DropoffOnly && entry.StationPosition.Code != "2",
+			canAlight: !entry.PickupOnly && entry.StationPosition.Code != "0",
+		})
+	}
+}
+
+func (p *Planner) addStop(trainId string, line raildata.Line, s stop) {
+	for _, r := range p.runs[trainId] {
+		first, last := r.stops[0].arrival, r.stops[len(r.stops)-1].departure
+		if s.departure.Before(first.Add(-maxRunGap)) || s.arrival.After(last.Add(maxRunGap)) {
+			continue
+		}
+		i := slices.IndexFunc(r.stops, func(o stop) bool { return o.station.Code == s.station.Code })
+		if i >= 0 {
+			r.stops[i] = s
+		} else {
+			r.stops = append(r.stops, s)
+		}
+		slices.SortStableFunc(r.stops, func(a, b stop) int { return a.departure.Compare(b.departure) })
+		return
+	}
+	p.runs[trainId] = append(p.runs[trainId], &run{trainId: trainId, line: line, stops: []stop{s}})
+}
+
+// AddTrainSchedule applies the delays and cancellations in a GetTrainSchedule response to the timetable.
+// A train's delay applies to all its stops.
+func (p *Planner) AddTrainSchedule(schedule *raildata.GetTrainScheduleResponse) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i := range schedule.Entries {
+		entry := &schedule.Entries[i]
+		status := liveStatus{station: schedule.Station.Code, departure: entry.DepartureTime}
+		if entry.Delay != nil {
+			status.delay = *entry.Delay
+		}
+		status.cancelled = entry.IsCancelled()
+		statuses := slices.DeleteFunc(p.live[entry.TrainId], func(s liveStatus) bool {
+			return s.station == status.station && s.departure.Equal(status.departure)
+		})
+		p.live[entry.TrainId] = append(statuses, status)
+	}
+}
+
+// status returns the most relevant live status for a run: the one reported for the run's stop with the latest departure.
+func (p *Planner) status(r *run) (liveStatus, bool) {
+	var out liveStatus
+	found := false
+	for _, status := range p.live[r.trainId] {
+		i := slices.IndexFunc(r.stops, func(s stop) bool {
+			return s.station.Code == status.station && s.departure.Equal(status.departure)
+		})
+		if i < 0 {
+			continue
+		}
+		if !found || status.departure.After(out.departure) {
+			out = status
+			found = true
+		}
+	}
+	return out, found
+}
+
+// connection contains a train's movement between two consecutive stops in the timetable.
+type connection struct {
+	run       *run
+	from      int
+	to        int
+	departure time.Time
+	arrival   time.Time
+	delay     time.Duration
+}
+
+// Search returns the best trips from one station to another that depart at or after the given time,
+// using the timetable and delays that have already been loaded.
+//
+// The trips are sorted by arrival time. Trips that depart earlier and arrive later than another trip,
+// or that need more transfers for the same times, are not returned.
+func (p *Planner) Search(from raildata.StationCode, to raildata.StationCode, departAfter time.Time) ([]Trip, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, station := range []raildata.StationCode{from, to} {
+		if _, found := p.stations[station]; !found {
+			return nil, fmt.Errorf("the schedule for station %s has not been loaded", station)
+		}
+	}
+	if from == to {
+		return nil, nil
+	}
+
+	connections := p.connections()
+	var trips []Trip
+	after := departAfter
+	for i := 0; p.maxTrips <= 0 || i < p.maxTrips*3; i++ {
+		trip, found := p.search(connections, from, to, after)
+		if !found {
+			break
+		}
+		trips = append(trips, trip)
+		after = trip.Departure.Add(time.Second)
+	}
+	trips = slices.DeleteFunc(trips, func(t Trip) bool {
+		return slices.ContainsFunc(trips, func(o Trip) bool { return dominates(&o, &t) })
+	})
+	slices.SortStableFunc(trips, func(a, b Trip) int {
+		return cmp.Or(a.Arrival.Compare(b.Arrival), b.Departure.Compare(a.Departure), cmp.Compare(a.Transfers(), b.Transfers()))
+	})
+	trips = slices.CompactFunc(trips, func(a, b Trip) bool {
+		return slices.Equal(a.TrainIds(), b.TrainIds()) && a.Departure.Equal(b.Departure)
+	})
+	if p.maxTrips > 0 && len(trips) > p.maxTrips {
+		trips = trips[:p.maxTrips]
+	}
+	return trips, nil
+}
+
+// dominates returns whether trip a is strictly better than trip b.
+func dominates(a *Trip, b *Trip) bool {
+	if a.Departure.Before(b.Departure) || a.Arrival.After(b.Arrival) || a.Transfers() > b.Transfers() {
+		return false
+	}
+	return !a.Departure.Equal(b.Departure) || !a.Arrival.Equal(b.Arrival) || a.Transfers() < b.Transfers()
+}
+
+// connections returns all the connections in the timetable, sorted by departure time.
+func (p *Planner) connections() []connection {
+	var out []connection
+	for _, runs := range p.runs {
+		for _, r := range runs {
+			status, _ := p.status(r)
+			if status.cancelled {
+				continue
+			}
+			for i := 1; i < len(r.stops); i++ {
+				out = append(out, connection{
+					run:       r,
+					from:      i - 1,
+					to:        i,
+					departure: r.stops[i-1].departure.Add(status.delay),
+					arrival:   r.stops[i].arrival.Add(status.delay),
+					delay:     status.delay,
+				})
+			}
+		}
+	}
+	slices.SortFunc(out, func(a, b connection) int {
+		return cmp.Or(a.departure.Compare(b.departure), a.arrival.Compare(b.arrival), strings.Compare(a.run.trainId, b.run.trainId))
+	})
+	return out
+}
+
+// label contains how the earliest arrival at a station was achieved.
+type label struct {
+	// ready contains the earliest time a passenger at this station can board a train.
+	ready time.Time
+	// arrival contains the time the passenger arrives at this station.
+	arrival time.Time
+	// leg contains the leg that brought the passenger to this station, if any.
+	leg *Leg
+	// prev contains the label of the station where the passenger boarded the leg or started walking.
+	prev *label
+}
+
+// search runs the connection scan algorithm to find the trip that arrives earliest at the destination.
+func (p *Planner) search(connections []connection, from raildata.StationCode, to raildata.StationCode, departAfter time.Time) (Trip, bool) {
+	labels := map[raildata.StationCode]*label{from: {ready: departAfter, arrival: departAfter}}
+	p.walk(labels, from)
+	type boarding struct {
+		stop  int
+		prior *label
+	}
+	boarded := map[*run]boarding{}
+
+	for i := range connections {
+		c := &connections[i]
+		if c.departure.Before(departAfter) {
+			continue
+		}
+		if dest, found := labels[to]; found && !c.departure.Before(dest.arrival) {
+			break
+		}
+		b, onboard := boarded[c.run]
+		if !onboard {
+			source := c.run.stops[c.from]
+			l, found := labels[source.station.Code]
+			if !found || !source.canBoard || c.departure.Before(l.ready) {
+				continue
+			}
+			b = boarding{stop: c.from, prior: l}
+			boarded[c.run] = b
+		}
+		target := c.run.stops[c.to]
+		if !target.canAlight {
+			continue
+		}
+		if l, found := labels[target.station.Code]; found && !c.arrival.Before(l.arrival) {
+			continue
+		}
+		source := c.run.stops[b.stop]
+		leg := &Leg{
+			TrainId:   c.run.trainId,
+			Line:      c.run.line,
+			From:      source.station,
+			To:        target.station,
+			Departure: source.departure.Add(c.delay),
+			Arrival:   c.arrival,
+			Delay:     c.delay,
+		}
+		labels[target.station.Code] = &label{ready: c.arrival.Add(p.minTransfer), arrival: c.arrival, leg: leg, prev: b.prior}
+		p.walk(labels, target.station.Code)
+	}
+
+	dest, found := labels[to]
+	if !found || dest.prev == nil {
+		return Trip{}, false
+	}
+	var trip Trip
+	for l := dest; l.prev != nil; l = l.prev {
+		if l.leg != nil {
+			trip.Legs = append(trip.Legs, *l.leg)
+		}
+	}
+	if len(trip.Legs) == 0 {
+		return Trip{}, false
+	}
+	slices.Reverse(trip.Legs)
+	for i := 1; i < len(trip.Legs); i++ {
+		trip.Legs[i].Wait = trip.Legs[i].Departure.Sub(trip.Legs[i-1].Arrival)
+	}
+	trip.Departure = trip.Legs[0].Departure
+	trip.Arrival = dest.arrival
+	return trip, true
+}
+
+// walk updates the labels of the stations that can be reached on foot from the given station.
+func (p *Planner) walk(labels map[raildata.StationCode]*label, from raildata.StationCode) {
+	source := labels[from]
+	for _, transfer := range p.walks[from] {
+		arrival := source.arrival.Add(transfer.Time)
+		if l, found := labels[transfer.To]; found && !arrival.Before(l.arrival) {
+			continue
+		}
+		labels[transfer.To] = &label{ready: arrival, arrival: arrival, prev: source}
+	}
+}
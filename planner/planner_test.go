@@ -0,0 +1,255 @@
+package planner_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/planner"
+	"github.com/jtarrio/raildata/raildatatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTrain(id string, lineCode raildata.LineCode, stops ...raildatatest.Stop) raildatatest.Train {
+	return raildatatest.Train{TrainId: id, Line: raildatatest.Line(lineCode), Direction: raildata.DirectionWestbound, Status: "On Time", Stops: stops}
+}
+
+func stopAt(code raildata.StationCode, minutes int) raildatatest.Stop {
+	return raildatatest.Stop{Station: raildatatest.Station(code), Time: raildatatest.At(minutes), Dwell: time.Minute}
+}
+
+// newServer returns a server with trains from Montclair State University (UV) to Princeton (PR),
+// transferring at Secaucus (SE) and Princeton Junction (PJ).
+func newServer() *raildatatest.Server {
+	server := raildatatest.NewServer()
+	server.AddTrain(newTrain("6000", "MC", stopAt("UV", 0), stopAt("ND", 25), stopAt("SE", 40), stopAt("NY", 50)))
+	server.AddTrain(newTrain("6002", "MC", stopAt("UV", 60), stopAt("ND", 85), stopAt("SE", 100), stopAt("NY", 110)))
+	server.AddTrain(newTrain("3850", "NE", stopAt("NY", 45), stopAt("SE", 55), stopAt("NP", 65), stopAt("PJ", 110)))
+	server.AddTrain(newTrain("3860", "NE", stopAt("NY", 105), stopAt("SE", 115), stopAt("NP", 125), stopAt("PJ", 170)))
+	server.AddTrain(newTrain("4000", "PR", stopAt("PJ", 120), stopAt("PR", 125)))
+	server.AddTrain(newTrain("4002", "PR", stopAt("PJ", 180), stopAt("PR", 185)))
+	return server
+}
+
+var montclairToPrinceton = []raildata.StationCode{"UV", "SE", "PJ", "PR"}
+
+func newPlanner(t *testing.T, server *raildatatest.Server, stations []raildata.StationCode, options ...planner.Option) *planner.Planner {
+	p := planner.New(server.NewClient(t), options...)
+	require.NoError(t, p.Load(context.Background(), stations...))
+	return p
+}
+
+func TestPlanWithTransfers(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	p := newPlanner(t, server, montclairToPrinceton)
+
+	assert.Equal(t, []raildata.StationCode{"PJ", "PR", "SE", "UV"}, p.Stations())
+
+	trips, err := p.Plan(context.Background(), "UV", "PR", raildatatest.At(-10))
+	require.NoError(t, err)
+	require.Len(t, trips, 2)
+
+	trip := trips[0]
+	assert.Equal(t, []string{"6000", "3850", "4000"}, trip.TrainIds())
+	assert.Equal(t, 2, trip.Transfers())
+	assert.WithinDuration(t, raildatatest.At(1), trip.Departure, 0)
+	assert.WithinDuration(t, raildatatest.At(125), trip.Arrival, 0)
+	assert.Equal(t, 124*time.Minute, trip.Duration())
+	require.Len(t, trip.Legs, 3)
+	assert.Equal(t, raildata.StationCode("UV"), trip.Legs[0].From.Code)
+	assert.Equal(t, raildata.StationCode("SE"), trip.Legs[0].To.Code)
+	assert.Equal(t, raildata.LineCode("MC"), trip.Legs[0].Line.Code)
+	assert.WithinDuration(t, raildatatest.At(40), trip.Legs[0].Arrival, 0)
+	assert.Zero(t, trip.Legs[0].Wait)
+	assert.Equal(t, raildata.StationCode("SE"), trip.Legs[1].From.Code)
+	assert.Equal(t, raildata.StationCode("PJ"), trip.Legs[1].To.Code)
+	assert.WithinDuration(t, raildatatest.At(56), trip.Legs[1].Departure, 0)
+	assert.Equal(t, 16*time.Minute, trip.Legs[1].Wait)
+	assert.Equal(t, 11*time.Minute, trip.Legs[2].Wait)
+	assert.Equal(t, 27*time.Minute, trip.TransferWait())
+
+	assert.Equal(t, []string{"6002", "3860", "4002"}, trips[1].TrainIds())
+	assert.WithinDuration(t, raildatatest.At(185), trips[1].Arrival, 0)
+}
+
+func TestSearchMaxTrips(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+
+	p := newPlanner(t, server, montclairToPrinceton, planner.WithMaxTrips(1))
+	trips, err := p.Search("UV", "PR", raildatatest.At(-10))
+	require.NoError(t, err)
+	assert.Len(t, trips, 1)
+
+	server.ResetUsage()
+	for _, n := range []int{0, -1} {
+		p = newPlanner(t, server, montclairToPrinceton, planner.WithMaxTrips(n))
+		trips, err = p.Search("UV", "PR", raildatatest.At(-10))
+		require.NoError(t, err)
+		assert.Len(t, trips, 2)
+		server.ResetUsage()
+	}
+}
+
+func TestPlanAppliesDelays(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	p := newPlanner(t, server, montclairToPrinceton)
+
+	server.UpdateTrain("3850", func(train *raildatatest.Train) { train.Delay = 10 * time.Minute })
+	trips, err := p.Plan(context.Background(), "SE", "PJ", raildatatest.At(50))
+	require.NoError(t, err)
+	require.NotEmpty(t, trips)
+	assert.Equal(t, []string{"3850"}, trips[0].TrainIds())
+	assert.Equal(t, 10*time.Minute, trips[0].Legs[0].Delay)
+	assert.WithinDuration(t, raildatatest.At(66), trips[0].Departure, 0)
+	assert.WithinDuration(t, raildatatest.At(120), trips[0].Arrival, 0)
+
+	// The delayed train arrives at Princeton Junction too late to make the connection to 4000,
+	// so the earlier trip is not returned: it arrives at the same time as the later one.
+	trips, err = p.Search("UV", "PR", raildatatest.At(-10))
+	require.NoError(t, err)
+	require.Len(t, trips, 1)
+	assert.Equal(t, []string{"6002", "3860", "4002"}, trips[0].TrainIds())
+}
+
+func TestPlanSkipsCancelledTrains(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	p := newPlanner(t, server, montclairToPrinceton)
+
+	server.UpdateTrain("6000", func(train *raildatatest.Train) { train.Status = "CANCELLED" })
+	trips, err := p.Plan(context.Background(), "UV", "PR", raildatatest.At(-10))
+	require.NoError(t, err)
+	require.Len(t, trips, 1)
+	assert.Equal(t, []string{"6002", "3860", "4002"}, trips[0].TrainIds())
+}
+
+func TestPlanWalkingTransfer(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	server.AddTrain(newTrain("1000", "ML", stopAt("HB", 0), stopAt("TS", 10), stopAt("RW", 30)))
+	server.AddTrain(newTrain("1002", "NE", stopAt("SE", 15), stopAt("NP", 25)))
+	server.AddTrain(newTrain("1004", "NE", stopAt("SE", 25), stopAt("NP", 35)))
+	p := newPlanner(t, server, []raildata.StationCode{"HB", "TS", "SE", "NP"})
+
+	trips, err := p.Search("HB", "PJ", raildatatest.At(0))
+	assert.Error(t, err)
+	assert.Empty(t, trips)
+
+	trips, err = p.Search("HB", "NP", raildatatest.At(-5))
+	require.NoError(t, err)
+	require.Len(t, trips, 1)
+	// Going from the lower level to the upper level at Secaucus takes 10 minutes, so 1002 can't be caught.
+	assert.Equal(t, []string{"1000", "1004"}, trips[0].TrainIds())
+	assert.Equal(t, raildata.StationCode("TS"), trips[0].Legs[0].To.Code)
+	assert.Equal(t, raildata.StationCode("SE"), trips[0].Legs[1].From.Code)
+	assert.Equal(t, 16*time.Minute, trips[0].Legs[1].Wait)
+
+	server.ResetUsage()
+	p = newPlanner(t, server, []raildata.StationCode{"HB", "TS", "SE", "NP"}, planner.WithWalkingTransfers(nil))
+	trips, err = p.Search("HB", "NP", raildatatest.At(-5))
+	require.NoError(t, err)
+	assert.Empty(t, trips)
+}
+
+func TestTransferStations(t *testing.T) {
+	p := planner.New(nil)
+	assert.Equal(t, []raildata.StationCode{"SE", "PJ"}, p.TransferStations("UV", "PR"))
+	assert.Equal(t, []raildata.StationCode{"TS", "SE"}, p.TransferStations("RW", "NP"))
+	assert.Empty(t, p.TransferStations("HB", "NP"))
+	assert.Empty(t, p.TransferStations("UV", "UV"))
+}
+
+func TestPlanLoadsTransferStations(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	p := newPlanner(t, server, nil)
+
+	trips, err := p.Plan(context.Background(), "UV", "PR", raildatatest.At(-10))
+	require.NoError(t, err)
+	assert.Equal(t, []raildata.StationCode{"PJ", "PR", "SE", "UV"}, p.Stations())
+	assert.Equal(t, 4, server.RequestCount("getStationSchedule"))
+	require.Len(t, trips, 2)
+	assert.Equal(t, []string{"6000", "3850", "4000"}, trips[0].TrainIds())
+
+	// The schedules are only loaded once.
+	_, err = p.Plan(context.Background(), "UV", "PR", raildatatest.At(-10))
+	require.NoError(t, err)
+	assert.Equal(t, 4, server.RequestCount("getStationSchedule"))
+}
+
+func TestPrepareReloadsAfterTTL(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	now := raildatatest.At(-10)
+	client := server.NewClient(t, raildata.WithQuotaLimit("getStationSchedule", 100))
+	p := planner.New(client, planner.WithClock(func() time.Time { return now }))
+
+	require.NoError(t, p.Prepare(context.Background(), "UV", "PR"))
+	assert.Equal(t, 4, server.RequestCount("getStationSchedule"))
+
+	now = now.Add(planner.DefaultScheduleTTL - time.Minute)
+	require.NoError(t, p.Prepare(context.Background(), "UV", "PR"))
+	assert.Equal(t, 4, server.RequestCount("getStationSchedule"))
+
+	now = now.Add(2 * time.Minute)
+	server.ResetUsage()
+	require.NoError(t, p.Prepare(context.Background(), "UV", "PR"))
+	assert.Equal(t, 8, server.RequestCount("getStationSchedule"))
+}
+
+func TestPrepareDoesntReloadAddedSchedules(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	now := raildatatest.At(-10)
+	p := planner.New(server.NewClient(t), planner.WithClock(func() time.Time { return now }))
+	response, err := server.NewClient(t).RateLimitedMethods().GetStationSchedule(context.Background(), &raildata.GetStationScheduleRequest{StationCode: "UV", NjtOnly: true})
+	require.NoError(t, err)
+	for _, schedule := range response.Entries {
+		p.AddStationSchedule(schedule)
+	}
+
+	now = now.Add(2 * planner.DefaultScheduleTTL)
+	require.NoError(t, p.Prepare(context.Background(), "UV", "SE"))
+	assert.Equal(t, 2, server.RequestCount("getStationSchedule"))
+}
+
+func TestPrepareToleratesTransferStationFailures(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	now := raildatatest.At(-10)
+	client := server.NewClient(t, raildata.WithQuotaLimit("getStationSchedule", 100))
+	p := planner.New(client, planner.WithClock(func() time.Time { return now }))
+	require.NoError(t, p.Load(context.Background(), "UV", "PR"))
+
+	server.InjectFault("getStationSchedule", raildatatest.Fault{StatusCode: http.StatusBadRequest, Count: 1})
+	trips, err := p.Plan(context.Background(), "UV", "PR", now)
+	require.NoError(t, err)
+	assert.Empty(t, trips)
+	assert.Equal(t, []raildata.StationCode{"PJ", "PR", "UV"}, p.Stations())
+	assert.Equal(t, 4, server.RequestCount("getStationSchedule"))
+
+	// The failed station is not retried until the retry interval has elapsed.
+	require.NoError(t, p.Prepare(context.Background(), "UV", "PR"))
+	assert.Equal(t, 4, server.RequestCount("getStationSchedule"))
+
+	now = now.Add(planner.DefaultRetryInterval)
+	trips, err = p.Plan(context.Background(), "UV", "PR", raildatatest.At(-10))
+	require.NoError(t, err)
+	assert.Equal(t, 5, server.RequestCount("getStationSchedule"))
+	require.Len(t, trips, 2)
+}
+
+func TestPrepareFailsWithoutOrigin(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	p := planner.New(server.NewClient(t))
+
+	server.InjectFault("getStationSchedule", raildatatest.Fault{StatusCode: http.StatusBadRequest, Count: 1})
+	assert.Error(t, p.Prepare(context.Background(), "UV", "PR"))
+}
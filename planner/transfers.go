@@ -0,0 +1,184 @@
+package planner
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jtarrio/raildata"
+)
+
+// Prepare loads the schedules that are needed to plan trips between two stations: the origin, the destination,
+// and the stations returned by [Planner.TransferStations].
+//
+// A schedule is only retrieved if it hasn't been loaded yet, or if it was retrieved by the planner longer than
+// the schedule TTL ago. After a failed attempt, the schedule is not retrieved again until the retry interval
+// has elapsed. Prepare only returns an error if the origin's or the destination's schedule is not available;
+// if a transfer station's schedule can't be retrieved, the trips that go through it are not found.
+func (p *Planner) Prepare(ctx context.Context, from raildata.StationCode, to raildata.StationCode) error {
+	for _, station := range p.needsLoading(slices.Concat([]raildata.StationCode{from, to}, p.TransferStations(from, to))) {
+		if err := p.load(ctx, station); err != nil && (station == from || station == to) && !p.isLoaded(station) {
+			return err
+		}
+	}
+	return nil
+}
+
+// needsLoading returns the stations whose schedules need to be retrieved, without duplicates.
+func (p *Planner) needsLoading(stations []raildata.StationCode) []raildata.StationCode {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	now := p.now()
+	var out []raildata.StationCode
+	for _, station := range stations {
+		if slices.Contains(out, station) {
+			continue
+		}
+		if failed, found := p.failed[station]; found && now.Sub(failed) < p.retryInterval {
+			continue
+		}
+		fetched, found := p.fetched[station]
+		if _, loaded := p.stations[station]; loaded && (!found || now.Sub(fetched) < p.scheduleTTL) {
+			// Schedules added with AddStationSchedule are kept until the caller replaces them.
+			continue
+		}
+		out = append(out, station)
+	}
+	return out
+}
+
+func (p *Planner) isLoaded(station raildata.StationCode) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	_, found := p.stations[station]
+	return found
+}
+
+// TransferStations returns the stations where a passenger may need to change trains to travel from one station
+// to another, in the order the passenger would visit them.
+//
+// The stations are found using [raildata.LineTopologies]: for every way of getting from the lines that serve the
+// origin to the lines that serve the destination with as few changes of line as possible, the passenger changes
+// trains at the first station shared by both lines, or connected by a walking transfer. Amtrak and SEPTA lines
+// are not considered.
+func (p *Planner) TransferStations(from raildata.StationCode, to raildata.StationCode) []raildata.StationCode {
+	var out []raildata.StationCode
+	for _, path := range p.linePaths(njtLinesServing(from), njtLinesServing(to)) {
+		current := from
+		for i := 1; i < len(path); i++ {
+			leave, board, found := p.transferBetween(path[i-1], path[i], current)
+			if !found {
+				break
+			}
+			for _, station := range []raildata.StationCode{leave, board} {
+				if station != from && station != to && !slices.Contains(out, station) {
+					out = append(out, station)
+				}
+			}
+			current = board
+		}
+	}
+	return out
+}
+
+// transferBetween returns the station where a passenger traveling on line a from station current leaves the train,
+// and the station where the passenger boards a train on line b, choosing the closest one to current.
+func (p *Planner) transferBetween(a raildata.LineCode, b raildata.LineCode, current raildata.StationCode) (raildata.StationCode, raildata.StationCode, bool) {
+	stations := lineStations(b)
+	var leave, board raildata.StationCode
+	best := -1
+	for _, station := range lineStations(a) {
+		distance := len(raildata.StationsBetween(a, current, station))
+		if distance == 0 {
+			continue
+		}
+		candidates := []raildata.StationCode{station}
+		for _, walk := range p.walks[station] {
+			candidates = append(candidates, walk.To)
+		}
+		for _, candidate := range candidates {
+			if !slices.Contains(stations, candidate) {
+				continue
+			}
+			if best < 0 || distance < best {
+				leave, board, best = station, candidate, distance
+			}
+		}
+	}
+	return leave, board, best >= 0
+}
+
+// linePaths returns the shortest sequences of lines that go from any of the origin lines to any of the destination lines,
+// where consecutive lines share a station or are connected by a walking transfer.
+func (p *Planner) linePaths(origins []raildata.LineCode, destinations []raildata.LineCode) [][]raildata.LineCode {
+	var paths [][]raildata.LineCode
+	for _, origin := range origins {
+		paths = append(paths, []raildata.LineCode{origin})
+	}
+	visited := slices.Clone(origins)
+	for len(paths) > 0 {
+		var done [][]raildata.LineCode
+		for _, path := range paths {
+			if slices.Contains(destinations, path[len(path)-1]) {
+				done = append(done, path)
+			}
+		}
+		if len(done) > 0 {
+			return done
+		}
+		var next [][]raildata.LineCode
+		var reached []raildata.LineCode
+		for _, path := range paths {
+			for _, line := range p.connectedLines(path[len(path)-1]) {
+				if slices.Contains(visited, line) {
+					continue
+				}
+				next = append(next, append(slices.Clone(path), line))
+				if !slices.Contains(reached, line) {
+					reached = append(reached, line)
+				}
+			}
+		}
+		visited = append(visited, reached...)
+		paths = next
+	}
+	return nil
+}
+
+// connectedLines returns the NJ Transit lines that share a station with the given line,
+// or that serve a station connected to the line by a walking transfer.
+func (p *Planner) connectedLines(line raildata.LineCode) []raildata.LineCode {
+	var out []raildata.LineCode
+	for _, station := range lineStations(line) {
+		reachable := []raildata.StationCode{station}
+		for _, walk := range p.walks[station] {
+			reachable = append(reachable, walk.To)
+		}
+		for _, other := range njtLinesServing(reachable...) {
+			if other != line && !slices.Contains(out, other) {
+				out = append(out, other)
+			}
+		}
+	}
+	return out
+}
+
+func lineStations(line raildata.LineCode) []raildata.StationCode {
+	topology, found := raildata.GetLineTopology(line)
+	if !found {
+		return nil
+	}
+	return topology.Stations()
+}
+
+// njtLinesServing returns the codes of the NJ Transit lines that serve any of the given stations.
+func njtLinesServing(stations ...raildata.StationCode) []raildata.LineCode {
+	var out []raildata.LineCode
+	for _, station := range stations {
+		for _, line := range raildata.LinesServing(station) {
+			if line != "AM" && line != "SP" && !slices.Contains(out, line) {
+				out = append(out, line)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,224 @@
+package raildata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jtarrio/raildata/api"
+	rderrors "github.com/jtarrio/raildata/errors"
+	"github.com/rogpeppe/go-internal/lockedfile"
+)
+
+// DefaultQuotaLimits contains the default number of calls per day allowed for each RailData API method.
+// Methods that are not listed here don't have a daily limit.
+var DefaultQuotaLimits = map[string]int{
+	api.GetToken.Name:           5,
+	api.IsValidToken.Name:       10,
+	api.GetStationSchedule.Name: 5,
+}
+
+// QuotaUsage contains the daily usage count for a RailData API method.
+type QuotaUsage struct {
+	// Method contains the name of the RailData API method.
+	Method string
+	// Limit contains the number of calls allowed per day.
+	Limit int
+	// Usage contains the number of calls made today.
+	Usage int
+	// ResetTime contains the date/time when the usage count will be reset.
+	ResetTime time.Time
+}
+
+// QuotaStore persists the number of calls made to each RailData API method per day.
+//
+// Implementations must be safe for concurrent use.
+type QuotaStore interface {
+	// Update atomically replaces the count for the given method and day with the value returned by
+	// the update function, which receives the current count. It returns the new count.
+	Update(method string, day string, update func(count int) int) (int, error)
+}
+
+// NewMemoryQuotaStore returns a [QuotaStore] that keeps the counts in memory.
+func NewMemoryQuotaStore() QuotaStore {
+	return &memoryQuotaStore{}
+}
+
+type memoryQuotaStore struct {
+	mutex  sync.Mutex
+	counts quotaCounts
+}
+
+func (q *memoryQuotaStore) Update(method string, day string, update func(count int) int) (int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.counts.update(method, day, update), nil
+}
+
+// NewFileQuotaStore returns a [QuotaStore] that keeps the counts in a file.
+// The file is locked while it's being updated, so several processes can share it.
+func NewFileQuotaStore(name string) QuotaStore {
+	return &fileQuotaStore{name: name}
+}
+
+type fileQuotaStore struct {
+	name string
+}
+
+func (q *fileQuotaStore) Update(method string, day string, update func(count int) int) (int, error) {
+	var count int
+	err := lockedfile.Transform(q.name, func(old []byte) ([]byte, error) {
+		var counts quotaCounts
+		if len(old) > 0 {
+			if err := json.Unmarshal(old, &counts); err != nil {
+				return nil, err
+			}
+		}
+		count = counts.update(method, day, update)
+		return json.Marshal(&counts)
+	})
+	return count, err
+}
+
+type quotaCounts struct {
+	Day    string         `json:"day"`
+	Counts map[string]int `json:"counts"`
+}
+
+func (q *quotaCounts) update(method string, day string, update func(count int) int) int {
+	if q.Day != day || q.Counts == nil {
+		q.Day = day
+		q.Counts = map[string]int{}
+	}
+	count := update(q.Counts[method])
+	q.Counts[method] = count
+	return count
+}
+
+// WithQuotaStore sets the store where the client records the number of calls made to each rate-limited method.
+// By default, the counts are kept in memory.
+//
+// If several processes share the same credentials, you should give them the same store, for example
+// one created with [NewFileQuotaStore].
+func WithQuotaStore(store QuotaStore) Option {
+	return func(s *raildataClient) {
+		s.quota.store = store
+	}
+}
+
+// WithQuotaLimit sets the number of calls per day allowed for the given method.
+// A zero or negative value removes the limit.
+func WithQuotaLimit(method string, limit int) Option {
+	return func(s *raildataClient) {
+		if s.quota.limits == nil {
+			s.quota.limits = map[string]int{}
+		}
+		s.quota.limits[method] = limit
+	}
+}
+
+type quotaTracker struct {
+	store  QuotaStore
+	limits map[string]int
+}
+
+func (q *quotaTracker) limit(method string) int {
+	if limit, found := q.limits[method]; found {
+		return limit
+	}
+	return DefaultQuotaLimits[method]
+}
+
+// acquire records a call to the given method, or returns a QuotaExceededError if the limit has been reached.
+func (q *quotaTracker) acquire(method string) error {
+	limit := q.limit(method)
+	if limit <= 0 {
+		return nil
+	}
+	now := time.Now()
+	exceeded := false
+	count, err := q.store.Update(method, quotaDay(now), func(count int) int {
+		if count >= limit {
+			exceeded = true
+			return count
+		}
+		return count + 1
+	})
+	if err != nil {
+		return err
+	}
+	if exceeded {
+		return rderrors.NewQuotaExceededError(method, limit, count, quotaResetTime(now), nil)
+	}
+	return nil
+}
+
+// update processes an error returned by the RailData API, recording the reported usage and
+// filling in the reset time.
+func (q *quotaTracker) update(err error) error {
+	var qerr *rderrors.QuotaExceededError
+	if !errors.As(err, &qerr) {
+		return err
+	}
+	now := time.Now()
+	if qerr.ResetTime.IsZero() {
+		qerr.ResetTime = quotaResetTime(now)
+	}
+	_, _ = q.store.Update(qerr.Method, quotaDay(now), func(count int) int { return max(count, qerr.Usage) })
+	return err
+}
+
+func (q *quotaTracker) usage() ([]QuotaUsage, error) {
+	methods := map[string]bool{}
+	for method := range DefaultQuotaLimits {
+		methods[method] = true
+	}
+	for method := range q.limits {
+		methods[method] = true
+	}
+	now := time.Now()
+	var out []QuotaUsage
+	for _, method := range slices.Sorted(maps.Keys(methods)) {
+		limit := q.limit(method)
+		if limit <= 0 {
+			continue
+		}
+		count, err := q.store.Update(method, quotaDay(now), func(count int) int { return count })
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, QuotaUsage{Method: method, Limit: limit, Usage: count, ResetTime: quotaResetTime(now)})
+	}
+	return out, nil
+}
+
+func quotaDay(now time.Time) string {
+	return now.In(njLocation).Format(time.DateOnly)
+}
+
+func quotaResetTime(now time.Time) time.Time {
+	local := now.In(njLocation)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, njLocation)
+}
+
+func send[I any, O any](method api.MethodDefinition[I, O], s *raildataClient, ctx context.Context, input *I) ([]byte, error) {
+	if err := s.rateLimiter.wait(ctx, method.Name); err != nil {
+		return nil, err
+	}
+	if err := s.quota.acquire(method.Name); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	raw, err := method.RequestRaw(ctx, s.client, s.apiBase, input)
+	err = s.quota.update(err)
+	s.logCall(ctx, method.Name, time.Since(start), raw, err)
+	return raw, err
+}
+
+func (s *raildataClient) GetQuotaUsage() ([]QuotaUsage, error) {
+	return s.quota.usage()
+}
@@ -0,0 +1,87 @@
+package raildata_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaRefusesCallsOverLimit(t *testing.T) {
+	calls := 0
+	handler := expectRequest(t, "isValidToken", "token", testToken).sendJson(`{"validToken": true, "userID": "the-user-id"}`)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		handler.ServeHTTP(rw, req)
+	}))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithQuotaLimit("isValidToken", 2))
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = client.RateLimitedMethods().IsValidToken(context.Background())
+		require.NoError(t, err)
+	}
+	_, err = client.RateLimitedMethods().IsValidToken(context.Background())
+	var qerr *errors.QuotaExceededError
+	require.ErrorAs(t, err, &qerr)
+	assert.Equal(t, "isValidToken", qerr.Method)
+	assert.Equal(t, 2, qerr.Limit)
+	assert.Equal(t, 2, qerr.Usage)
+	assert.False(t, qerr.ResetTime.IsZero())
+	assert.Equal(t, 2, calls)
+
+	usage, err := client.GetQuotaUsage()
+	require.NoError(t, err)
+	var found bool
+	for _, u := range usage {
+		if u.Method == "isValidToken" {
+			found = true
+			assert.Equal(t, 2, u.Limit)
+			assert.Equal(t, 2, u.Usage)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestQuotaRecordsServerReportedUsage(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "isValidToken").sendError("Daily usage limit:10. Your current daily usage: 11"))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken))
+	require.NoError(t, err)
+
+	_, err = client.RateLimitedMethods().IsValidToken(context.Background())
+	var qerr *errors.QuotaExceededError
+	require.ErrorAs(t, err, &qerr)
+	assert.Equal(t, 11, qerr.Usage)
+
+	usage, err := client.GetQuotaUsage()
+	require.NoError(t, err)
+	for _, u := range usage {
+		if u.Method == "isValidToken" {
+			assert.Equal(t, 11, u.Usage)
+		}
+	}
+}
+
+func TestFileQuotaStoreIsShared(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "quota")
+	first := raildata.NewFileQuotaStore(name)
+	second := raildata.NewFileQuotaStore(name)
+
+	count, err := first.Update("getToken", "2025-01-17", func(count int) int { return count + 1 })
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	count, err = second.Update("getToken", "2025-01-17", func(count int) int { return count + 1 })
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	count, err = first.Update("getToken", "2025-01-18", func(count int) int { return count + 1 })
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,46 @@
+package raildatatest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+)
+
+// BaseTime contains an arbitrary weekday morning that tests can use as the current time.
+var BaseTime = time.Date(2025, 3, 10, 8, 0, 0, 0, time.FixedZone("EDT", -4*60*60))
+
+// At returns the time that is the given number of minutes after [BaseTime].
+func At(minutes int) time.Time {
+	return BaseTime.Add(time.Duration(minutes) * time.Minute)
+}
+
+// Station returns the station with the given code from [raildata.Stations]. It panics if there is no such station.
+func Station(code raildata.StationCode) raildata.Station {
+	s, found := raildata.FindStation().WithCode(code).Search()
+	if !found {
+		panic(fmt.Sprintf("unknown station %s", code))
+	}
+	return *s
+}
+
+// Line returns the line with the given code from [raildata.Lines]. It panics if there is no such line.
+func Line(code raildata.LineCode) raildata.Line {
+	l, found := raildata.FindLine().WithCode(code).Search()
+	if !found {
+		panic(fmt.Sprintf("unknown line %s", code))
+	}
+	return *l
+}
+
+// NewClient creates a [raildata.Client] that talks to this server with a valid token created with [Server.IssueToken].
+// The given options are applied after the server's, so they can replace the token or the credentials.
+func (s *Server) NewClient(t testing.TB, options ...raildata.Option) raildata.Client {
+	t.Helper()
+	client, err := raildata.NewClient(append(append(s.ClientOptions(), raildata.WithToken(s.IssueToken())), options...)...)
+	if err != nil {
+		t.Fatalf("could not create client: %v", err)
+	}
+	return client
+}
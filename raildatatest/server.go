@@ -0,0 +1,683 @@
+// Package raildatatest provides a fake RailData API server for integration tests.
+//
+// The server implements all the RailData API methods. It issues and expires tokens, simulates
+// the daily usage limits, can inject faults, and serves responses that are generated from
+// a world state of trains and messages that your tests can change at any time.
+//
+// Example:
+//
+//	server := raildatatest.NewServer()
+//	defer server.Close()
+//	server.AddTrain(raildatatest.Train{TrainId: "3887", Line: raildatatest.Line("NE"), ...})
+//	client := server.NewClient(t)
+package raildatatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/api"
+)
+
+// Default credentials accepted by the server.
+const (
+	DefaultUsername = "test-user"
+	DefaultPassword = "test-password"
+)
+
+// Train contains the state of a train in the fake world.
+type Train struct {
+	// TrainId contains the train's number.
+	TrainId string
+	// Line contains the line this train runs on.
+	Line raildata.Line
+	// Direction contains the train's direction of travel.
+	Direction raildata.Direction
+	// Destination contains the destination name. If empty, the name of the last stop is used.
+	Destination string
+	// Status contains the train's status, for example "BOARDING".
+	Status string
+	// Delay contains the train's current delay. It is added to the scheduled time of the stops that haven't been departed.
+	Delay time.Duration
+	// Location contains the train's GPS location. Only trains with a location are returned by getVehicleData.
+	Location *raildata.Location
+	// TrackCircuitId contains the last circuit id for this train.
+	TrackCircuitId string
+	// Stops contains the train's stops, in order.
+	Stops []Stop
+	// Capacity contains capacity records for this train, in the RailData API format.
+	Capacity []api.CapacityList
+}
+
+// Stop contains the state of a train's stop in the fake world.
+type Stop struct {
+	// Station contains the station where the train stops.
+	Station raildata.Station
+	// Time contains the scheduled arrival time.
+	Time time.Time
+	// Dwell contains how long the train waits at the station.
+	Dwell time.Duration
+	// Track contains the track the train uses at this station, if known.
+	Track string
+	// Departed indicates, if true, that the train has already left this station.
+	Departed bool
+	// Status contains an optional status at the stop, for example "Cancelled".
+	Status string
+}
+
+// Message contains a message or alert in the fake world.
+type Message struct {
+	// Type contains the type of message.
+	Type raildata.MsgType
+	// Text contains the message's text.
+	Text string
+	// PubDate contains the date/time the message was published.
+	PubDate time.Time
+	// Id contains an optional identifier for the message.
+	Id string
+	// Agency contains the optional agency that published the message.
+	Agency string
+	// Stations contains the stations this message pertains to.
+	Stations []raildata.Station
+	// Lines contains the lines this message pertains to.
+	Lines []raildata.Line
+}
+
+// Fault describes an error to return instead of a regular response.
+type Fault struct {
+	// StatusCode contains the HTTP status code to return. If zero, 500 is used.
+	StatusCode int
+	// ErrorMessage, if not empty, is returned as a RailData API error message.
+	ErrorMessage string
+	// Body, if ErrorMessage is empty, contains the response body to return.
+	Body string
+	// Delay contains how long to wait before responding.
+	Delay time.Duration
+	// Count contains the number of requests this fault applies to. If zero, it applies to all requests.
+	Count int
+}
+
+// Option is a function that configures the server.
+type Option func(*Server)
+
+// WithCredentials sets the username and password accepted by the server.
+func WithCredentials(username string, password string) Option {
+	return func(s *Server) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// WithTokenLifetime sets how long the tokens issued by the server are valid. By default, they don't expire.
+func WithTokenLifetime(lifetime time.Duration) Option {
+	return func(s *Server) {
+		s.tokenLifetime = lifetime
+	}
+}
+
+// WithDailyLimit sets the number of calls per day the server accepts for the given method.
+// By default, the limits in [raildata.DefaultQuotaLimits] are used.
+func WithDailyLimit(method string, limit int) Option {
+	return func(s *Server) {
+		s.limits[method] = limit
+	}
+}
+
+// WithClock sets the function that the server uses to get the current time.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// Server is a fake RailData API server.
+type Server struct {
+	server        *httptest.Server
+	mutex         sync.Mutex
+	now           func() time.Time
+	username      string
+	password      string
+	tokenLifetime time.Duration
+	tokens        map[string]time.Time
+	issued        int
+	limits        map[string]int
+	usage         map[string]int
+	requests      map[string]int
+	faults        map[string][]*Fault
+	trains        []Train
+	messages      []Message
+}
+
+// NewServer starts a fake RailData API server. Call [Server.Close] when you're done with it.
+func NewServer(options ...Option) *Server {
+	s := &Server{
+		now:      time.Now,
+		username: DefaultUsername,
+		password: DefaultPassword,
+		tokens:   map[string]time.Time{},
+		limits:   map[string]int{},
+		usage:    map[string]int{},
+		requests: map[string]int{},
+		faults:   map[string][]*Fault{},
+	}
+	for method, limit := range raildata.DefaultQuotaLimits {
+		s.limits[method] = limit
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// URL returns the server's base URL.
+func (s *Server) URL() url.URL {
+	u, err := url.Parse(s.server.URL)
+	if err != nil {
+		panic(err)
+	}
+	return *u
+}
+
+// ClientOptions returns the options to create a [raildata.Client] that talks to this server with valid credentials.
+//
+// Like the real server, this server doesn't accept requests without a token, so you also need to give the client
+// a token: either a valid one created with [Server.IssueToken], or any other value to make the client request a new one.
+func (s *Server) ClientOptions() []raildata.Option {
+	return []raildata.Option{
+		raildata.WithApiBase(s.URL()),
+		raildata.WithCredentials(s.username, s.password),
+	}
+}
+
+// IssueToken creates a valid token, without counting it against the daily limit.
+func (s *Server) IssueToken() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.issueToken()
+}
+
+// ExpireTokens makes all the issued tokens invalid.
+func (s *Server) ExpireTokens() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	clear(s.tokens)
+}
+
+// ResetUsage resets the daily usage counts, as happens at midnight.
+func (s *Server) ResetUsage() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	clear(s.usage)
+}
+
+// RequestCount returns the number of requests received for the given method.
+func (s *Server) RequestCount(method string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests[method]
+}
+
+// InjectFault makes the server return an error for the given method.
+// Faults are applied in the order they were injected.
+func (s *Server) InjectFault(method string, fault Fault) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults[method] = append(s.faults[method], &fault)
+}
+
+// ClearFaults removes all the faults.
+func (s *Server) ClearFaults() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	clear(s.faults)
+}
+
+// AddTrain adds a train to the world, replacing any train with the same id.
+func (s *Server) AddTrain(train Train) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.trains = slices.DeleteFunc(s.trains, func(t Train) bool { return t.TrainId == train.TrainId })
+	s.trains = append(s.trains, train)
+}
+
+// UpdateTrain calls the function to modify the train with the given id. It returns false if there is no such train.
+func (s *Server) UpdateTrain(trainId string, update func(*Train)) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range s.trains {
+		if s.trains[i].TrainId == trainId {
+			update(&s.trains[i])
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveTrain removes the train with the given id from the world.
+func (s *Server) RemoveTrain(trainId string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.trains = slices.DeleteFunc(s.trains, func(t Train) bool { return t.TrainId == trainId })
+}
+
+// AddMessage adds a message to the world.
+func (s *Server) AddMessage(message Message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.messages = append(s.messages, message)
+}
+
+// ClearMessages removes all the messages from the world.
+func (s *Server) ClearMessages() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.messages = nil
+}
+
+func (s *Server) issueToken() string {
+	s.issued++
+	token := fmt.Sprintf("fake-token-%d", s.issued)
+	s.tokens[token] = s.now()
+	return token
+}
+
+func (s *Server) validToken(token string) bool {
+	issued, found := s.tokens[token]
+	if !found {
+		return false
+	}
+	return s.tokenLifetime <= 0 || s.now().Before(issued.Add(s.tokenLifetime))
+}
+
+func (s *Server) serveHTTP(rw http.ResponseWriter, req *http.Request) {
+	method := path.Base(req.URL.Path)
+	if err := req.ParseMultipartForm(5000000); err != nil {
+		sendStatus(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mutex.Lock()
+	s.requests[method]++
+	fault := s.takeFault(method)
+	s.mutex.Unlock()
+	if fault != nil {
+		time.Sleep(fault.Delay)
+		statusCode := fault.StatusCode
+		if statusCode == 0 {
+			statusCode = http.StatusInternalServerError
+		}
+		if len(fault.ErrorMessage) > 0 {
+			sendError(rw, statusCode, fault.ErrorMessage)
+		} else {
+			sendStatus(rw, statusCode, fault.Body)
+		}
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if limit := s.limits[method]; limit > 0 {
+		s.usage[method]++
+		if s.usage[method] > limit {
+			sendError(rw, http.StatusInternalServerError, fmt.Sprintf("Daily usage limit:%d. Your current daily usage: %d", limit, s.usage[method]))
+			return
+		}
+	}
+
+	if method == api.GetToken.Name {
+		s.serveGetToken(rw, req)
+		return
+	}
+	token := req.Form.Get("token")
+	if len(token) == 0 {
+		sendStatus(rw, http.StatusNoContent, "")
+		return
+	}
+	if method == api.IsValidToken.Name {
+		sendJson(rw, &api.ValidTokenResponse{ValidToken: s.validToken(token), UserID: s.username})
+		return
+	}
+	if !s.validToken(token) {
+		sendError(rw, http.StatusInternalServerError, "Invalid token.")
+		return
+	}
+
+	switch method {
+	case api.GetStationList.Name:
+		sendJson(rw, s.stationList())
+	case api.GetStationMSG.Name:
+		sendJson(rw, s.stationMsgs(req.Form.Get("station"), req.Form.Get("line")))
+	case api.GetStationSchedule.Name:
+		sendJson(rw, s.stationSchedule(req.Form.Get("station"), strings.EqualFold(req.Form.Get("NJTOnly"), "true")))
+	case api.GetTrainSchedule.Name:
+		sendJson(rw, s.trainSchedule(req.Form.Get("station"), "", true))
+	case api.GetTrainSchedule19Rec.Name:
+		sendJson(rw, s.trainSchedule(req.Form.Get("station"), req.Form.Get("line"), false))
+	case api.GetTrainStopList.Name:
+		sendJson(rw, s.trainStopList(req.Form.Get("train")))
+	case api.GetVehicleData.Name:
+		sendJson(rw, s.vehicleData())
+	default:
+		sendStatus(rw, http.StatusNotFound, "")
+	}
+}
+
+func (s *Server) takeFault(method string) *Fault {
+	faults := s.faults[method]
+	if len(faults) == 0 {
+		return nil
+	}
+	fault := faults[0]
+	if fault.Count > 0 {
+		fault.Count--
+		if fault.Count == 0 {
+			s.faults[method] = faults[1:]
+		}
+	}
+	return fault
+}
+
+func (s *Server) serveGetToken(rw http.ResponseWriter, req *http.Request) {
+	if req.Form.Get("username") != s.username || req.Form.Get("password") != s.password {
+		sendJson(rw, &api.GetTokenResponse{Authenticated: "False"})
+		return
+	}
+	sendJson(rw, &api.GetTokenResponse{Authenticated: "True", UserToken: s.issueToken()})
+}
+
+func (s *Server) stationList() []api.GetStations {
+	var out []api.GetStations
+	for _, station := range raildata.Stations {
+		out = append(out, api.GetStations{STATION_2CHAR: string(station.Code), STATIONNAME: station.Name, STATION_14CHAR: station.ShortName})
+	}
+	return out
+}
+
+func (s *Server) stationMsgs(station string, line string) []api.StationMsgs {
+	out := []api.StationMsgs{}
+	for _, msg := range s.messages {
+		if len(station) > 0 && !slices.ContainsFunc(msg.Stations, func(st raildata.Station) bool { return string(st.Code) == station }) {
+			continue
+		}
+		if len(line) > 0 && !slices.ContainsFunc(msg.Lines, func(l raildata.Line) bool { return string(l.Code) == line }) {
+			continue
+		}
+		out = append(out, formatMessage(&msg))
+	}
+	return out
+}
+
+func (s *Server) stationSchedule(station string, njtOnly bool) []api.DailyStationInfo {
+	info := api.DailyStationInfo{STATION_2CHAR: station, STATIONNAME: stationName(station)}
+	for _, train := range s.sortedTrains(station) {
+		if njtOnly && (train.Line.Code == "AM" || train.Line.Code == "SP") {
+			continue
+		}
+		i := stopIndex(&train, station)
+		stop := &train.Stops[i]
+		info.ITEMS = append(info.ITEMS, api.DailyScheduleInfo{
+			SCHED_DEP_DATE:   formatTime(stop.Time.Add(stop.Dwell)),
+			DESTINATION:      destination(&train),
+			TRACK:            stop.Track,
+			LINE:             train.Line.Name,
+			TRAIN_ID:         train.TrainId,
+			STATION_POSITION: stationPosition(&train, i),
+			DIRECTION:        formatDirection(train.Direction),
+			DWELL_TIME:       strconv.Itoa(int(stop.Dwell.Seconds())),
+			STOP_CODE:        "S",
+		})
+	}
+	return []api.DailyStationInfo{info}
+}
+
+func (s *Server) trainSchedule(station string, line string, withStops bool) *api.StationInfo {
+	info := &api.StationInfo{STATION_2CHAR: station, STATIONNAME: stationName(station)}
+	info.STATIONMSGS = s.stationMsgs(station, "")
+	for _, train := range s.sortedTrains(station) {
+		if len(info.ITEMS) == 19 {
+			break
+		}
+		if len(line) > 0 && string(train.Line.Code) != line {
+			continue
+		}
+		i := stopIndex(&train, station)
+		if train.Stops[i].Departed {
+			continue
+		}
+		stop := &train.Stops[i]
+		item := api.ScheduleInfo{
+			SCHED_DEP_DATE:   formatTime(stop.Time.Add(stop.Dwell)),
+			DESTINATION:      destination(&train),
+			TRACK:            stop.Track,
+			LINE:             train.Line.Name,
+			TRAIN_ID:         train.TrainId,
+			STATUS:           train.Status,
+			SEC_LATE:         strconv.Itoa(int(train.Delay.Seconds())),
+			LAST_MODIFIED:    formatTime(s.now()),
+			BACKCOLOR:        train.Line.Color.Html(),
+			FORECOLOR:        "#FFFFFF",
+			SHADOWCOLOR:      "#000000",
+			STATION_POSITION: stationPosition(&train, i),
+			LINECODE:         string(train.Line.Code),
+			LINEABBREVIATION: train.Line.Abbreviation,
+			CAPACITY:         train.Capacity,
+		}
+		if train.Location != nil {
+			item.GPSLATITUDE = formatFloat(train.Location.Latitude)
+			item.GPSLONGITUDE = formatFloat(train.Location.Longitude)
+			item.GPSTIME = formatTime(s.now())
+		}
+		if withStops {
+			item.STOPS = formatStops(&train)
+		}
+		info.ITEMS = append(info.ITEMS, item)
+	}
+	return info
+}
+
+func (s *Server) trainStopList(trainId string) *api.Stops {
+	for _, train := range s.trains {
+		if train.TrainId != trainId {
+			continue
+		}
+		return &api.Stops{
+			TRAIN_ID:    train.TrainId,
+			LINECODE:    string(train.Line.Code),
+			BACKCOLOR:   train.Line.Color.Html(),
+			FORECOLOR:   "#FFFFFF",
+			SHADOWCOLOR: "#000000",
+			DESTINATION: destination(&train),
+			STOPS:       formatStops(&train),
+			CAPACITY:    train.Capacity,
+		}
+	}
+	return &api.Stops{}
+}
+
+func (s *Server) vehicleData() []api.VehicleDataInfo {
+	out := []api.VehicleDataInfo{}
+	for _, train := range s.trains {
+		if train.Location == nil {
+			continue
+		}
+		next := slices.IndexFunc(train.Stops, func(stop Stop) bool { return !stop.Departed })
+		if next < 0 {
+			continue
+		}
+		stop := &train.Stops[next]
+		out = append(out, api.VehicleDataInfo{
+			ID:             train.TrainId,
+			TRAIN_LINE:     train.Line.Name,
+			DIRECTION:      formatDirection(train.Direction),
+			ICS_TRACK_CKT:  train.TrackCircuitId,
+			LAST_MODIFIED:  formatTime(s.now()),
+			SCHED_DEP_TIME: formatTime(stop.Time.Add(stop.Dwell)),
+			SEC_LATE:       strconv.Itoa(int(train.Delay.Seconds())),
+			NEXT_STOP:      stop.Station.Name,
+			LONGITUDE:      formatFloat(train.Location.Longitude),
+			LATITUDE:       formatFloat(train.Location.Latitude),
+		})
+	}
+	return out
+}
+
+// sortedTrains returns the trains that stop at the given station, sorted by their scheduled time at the station.
+func (s *Server) sortedTrains(station string) []Train {
+	var out []Train
+	for _, train := range s.trains {
+		if stopIndex(&train, station) >= 0 {
+			out = append(out, train)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b Train) int {
+		return a.Stops[stopIndex(&a, station)].Time.Compare(b.Stops[stopIndex(&b, station)].Time)
+	})
+	return out
+}
+
+func stopIndex(train *Train, station string) int {
+	return slices.IndexFunc(train.Stops, func(s Stop) bool { return string(s.Station.Code) == station })
+}
+
+func stationName(code string) string {
+	if station, found := raildata.FindStation().WithCode(raildata.StationCode(code)).Search(); found {
+		return station.Name
+	}
+	return ""
+}
+
+func destination(train *Train) string {
+	if len(train.Destination) > 0 {
+		return train.Destination
+	}
+	if len(train.Stops) == 0 {
+		return ""
+	}
+	return train.Stops[len(train.Stops)-1].Station.Name
+}
+
+func stationPosition(train *Train, i int) string {
+	switch i {
+	case 0:
+		return "0"
+	case len(train.Stops) - 1:
+		return "2"
+	default:
+		return "1"
+	}
+}
+
+func formatStops(train *Train) []api.StopList {
+	var out []api.StopList
+	for _, stop := range train.Stops {
+		arrival := stop.Time
+		if !stop.Departed {
+			arrival = arrival.Add(train.Delay)
+		}
+		out = append(out, api.StopList{
+			STATION_2CHAR:   string(stop.Station.Code),
+			STATIONNAME:     stop.Station.Name,
+			TIME:            formatTime(arrival),
+			DEPARTED:        formatYesNo(stop.Departed),
+			STOP_STATUS:     stop.Status,
+			DEP_TIME:        formatTime(arrival.Add(stop.Dwell)),
+			TIME_UTC_FORMAT: arrival.UTC().Format(dateTimeFormat),
+		})
+	}
+	return out
+}
+
+func formatMessage(msg *Message) api.StationMsgs {
+	out := api.StationMsgs{
+		MSG_TYPE:        "banner",
+		MSG_TEXT:        msg.Text,
+		MSG_PUBDATE:     msg.PubDate.In(njLocation).Format(msgDateTimeFormat),
+		MSG_ID:          msg.Id,
+		MSG_AGENCY:      msg.Agency,
+		MSG_PUBDATE_UTC: msg.PubDate.UTC().Format(msgDateTimeFormat),
+	}
+	if msg.Type == raildata.MsgTypeFullScreen {
+		out.MSG_TYPE = "fullscreen"
+	}
+	var stations []string
+	for _, station := range msg.Stations {
+		stations = append(stations, "*"+station.Name)
+	}
+	out.MSG_STATION_SCOPE = strings.Join(stations, ",")
+	var lines []string
+	for _, line := range msg.Lines {
+		lines = append(lines, "*"+line.Name)
+	}
+	out.MSG_LINE_SCOPE = strings.Join(lines, ",")
+	return out
+}
+
+var njLocation = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}()
+
+const msgDateTimeFormat = "1/2/2006 3:04:05 PM"
+const dateTimeFormat = "02-Jan-2006 03:04:05 PM"
+
+func formatTime(t time.Time) string {
+	return t.In(njLocation).Format(dateTimeFormat)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 6, 64)
+}
+
+func formatDirection(direction raildata.Direction) string {
+	if direction == raildata.DirectionEastbound {
+		return "Eastbound"
+	}
+	return "Westbound"
+}
+
+func formatYesNo(b bool) string {
+	if b {
+		return "YES"
+	}
+	return "NO"
+}
+
+func sendJson(rw http.ResponseWriter, obj any) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		sendStatus(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.Header().Set("content-type", "application/json")
+	sendStatus(rw, http.StatusOK, string(b))
+}
+
+func sendError(rw http.ResponseWriter, statusCode int, message string) {
+	b, _ := json.Marshal(map[string]string{"errorMessage": message})
+	rw.Header().Set("content-type", "application/json")
+	sendStatus(rw, statusCode, string(b))
+}
+
+func sendStatus(rw http.ResponseWriter, statusCode int, body string) {
+	rw.WriteHeader(statusCode)
+	_, _ = rw.Write([]byte(body))
+}
@@ -0,0 +1,187 @@
+package raildatatest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/errors"
+	"github.com/jtarrio/raildata/raildatatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTrain(id string, start time.Time) raildatatest.Train {
+	return raildatatest.Train{
+		TrainId:   id,
+		Line:      raildatatest.Line("NE"),
+		Direction: raildata.DirectionEastbound,
+		Status:    "On Time",
+		Location:  &raildata.Location{Longitude: -74.5, Latitude: 40.3},
+		Stops: []raildatatest.Stop{
+			{Station: raildatatest.Station("TR"), Time: start, Dwell: time.Minute, Track: "1"},
+			{Station: raildatatest.Station("NP"), Time: start.Add(50 * time.Minute), Dwell: time.Minute, Track: "3"},
+			{Station: raildatatest.Station("NY"), Time: start.Add(70 * time.Minute)},
+		},
+	}
+}
+
+func TestServerIssuesTokens(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	client := server.NewClient(t, raildata.WithToken("stale-token"))
+
+	_, err := client.GetStationList(context.Background())
+	require.NoError(t, err)
+	assert.NotEmpty(t, client.GetToken())
+	assert.Equal(t, 1, server.RequestCount("getToken"))
+
+	valid, err := client.RateLimitedMethods().IsValidToken(context.Background())
+	require.NoError(t, err)
+	assert.True(t, valid.ValidToken)
+}
+
+func TestServerRejectsBadCredentials(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	client := server.NewClient(t, raildata.WithToken("stale-token"), raildata.WithCredentials("user", "wrong"))
+
+	_, err := client.GetStationList(context.Background())
+	assert.ErrorIs(t, err, errors.BadCredentialsError)
+}
+
+func TestServerExpiresTokens(t *testing.T) {
+	now := time.Now()
+	server := raildatatest.NewServer(raildatatest.WithTokenLifetime(time.Hour), raildatatest.WithClock(func() time.Time { return now }))
+	defer server.Close()
+	client := server.NewClient(t, raildata.WithToken("stale-token"))
+
+	_, err := client.GetStationList(context.Background())
+	require.NoError(t, err)
+	firstToken := client.GetToken()
+
+	now = now.Add(2 * time.Hour)
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+	assert.NotEqual(t, firstToken, client.GetToken())
+	assert.Equal(t, 2, server.RequestCount("getToken"))
+
+	server.ExpireTokens()
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, server.RequestCount("getToken"))
+}
+
+func TestServerEnforcesDailyLimit(t *testing.T) {
+	server := raildatatest.NewServer(raildatatest.WithDailyLimit("getStationSchedule", 1))
+	defer server.Close()
+	client := server.NewClient(t, raildata.WithQuotaLimit("getStationSchedule", 0))
+
+	_, err := client.RateLimitedMethods().GetStationSchedule(context.Background(), &raildata.GetStationScheduleRequest{StationCode: "NY"})
+	require.NoError(t, err)
+	_, err = client.RateLimitedMethods().GetStationSchedule(context.Background(), &raildata.GetStationScheduleRequest{StationCode: "NY"})
+	var qerr *errors.QuotaExceededError
+	require.ErrorAs(t, err, &qerr)
+	assert.Equal(t, 1, qerr.Limit)
+	assert.Equal(t, 2, qerr.Usage)
+
+	server.ResetUsage()
+	_, err = client.RateLimitedMethods().GetStationSchedule(context.Background(), &raildata.GetStationScheduleRequest{StationCode: "NY"})
+	assert.NoError(t, err)
+}
+
+func TestServerInjectsFaults(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	server.InjectFault("getVehicleData", raildatatest.Fault{StatusCode: http.StatusServiceUnavailable, Count: 1})
+	client := server.NewClient(t)
+
+	_, err := client.GetVehicleData(context.Background())
+	var herr *errors.HttpStatusError
+	require.ErrorAs(t, err, &herr)
+	assert.Equal(t, http.StatusServiceUnavailable, herr.StatusCode)
+
+	_, err = client.GetVehicleData(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestServerInjectsErrorMessages(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	server.InjectFault("getStationMSG", raildatatest.Fault{ErrorMessage: "Something broke"})
+	client := server.NewClient(t)
+
+	_, err := client.GetStationMsg(context.Background(), &raildata.GetStationMsgRequest{})
+	var rderr *errors.RailDataError
+	require.ErrorAs(t, err, &rderr)
+	assert.Equal(t, "Something broke", rderr.Error())
+}
+
+func TestServerServesTrains(t *testing.T) {
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	server := raildatatest.NewServer()
+	defer server.Close()
+	server.AddTrain(newTrain("3800", start))
+	server.AddTrain(newTrain("3802", start.Add(-30*time.Minute)))
+	client := server.NewClient(t)
+
+	schedule, err := client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NP"})
+	require.NoError(t, err)
+	assert.Equal(t, "Newark Penn Station", schedule.Station.Name)
+	require.Len(t, schedule.Entries, 2)
+	assert.Equal(t, "3802", schedule.Entries[0].TrainId)
+	assert.Equal(t, "3800", schedule.Entries[1].TrainId)
+	assert.Equal(t, raildata.LineCode("NE"), schedule.Entries[1].Line.Code)
+	assert.True(t, start.Add(51*time.Minute).Equal(schedule.Entries[1].DepartureTime))
+	assert.Equal(t, "1", schedule.Entries[1].StationPosition.Code)
+	assert.Len(t, schedule.Entries[1].Stops, 3)
+
+	server.UpdateTrain("3800", func(train *raildatatest.Train) {
+		train.Delay = 5 * time.Minute
+		train.Stops[0].Departed = true
+	})
+	stops, err := client.GetTrainStopList(context.Background(), &raildata.GetTrainStopListRequest{TrainId: "3800"})
+	require.NoError(t, err)
+	require.NotNil(t, stops)
+	assert.Equal(t, "New York Penn Station", stops.Destination)
+	require.Len(t, stops.Stops, 3)
+	assert.True(t, stops.Stops[0].Departed)
+	assert.True(t, start.Equal(*stops.Stops[0].ArrivalTime))
+	assert.True(t, start.Add(55*time.Minute).Equal(*stops.Stops[1].ArrivalTime))
+
+	server.RemoveTrain("3800")
+	_, err = client.GetTrainStopList(context.Background(), &raildata.GetTrainStopListRequest{TrainId: "3800"})
+	var notFound *errors.TrainNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+
+	vehicles, err := client.GetVehicleData(context.Background())
+	require.NoError(t, err)
+	require.Len(t, vehicles.Vehicles, 1)
+	assert.Equal(t, "3802", vehicles.Vehicles[0].TrainId)
+}
+
+func TestServerServesMessages(t *testing.T) {
+	server := raildatatest.NewServer()
+	defer server.Close()
+	server.AddMessage(raildatatest.Message{Text: "Elevator out of service", PubDate: time.Now(), Stations: []raildata.Station{raildatatest.Station("NP")}})
+	server.AddMessage(raildatatest.Message{Text: "Delays on NEC", PubDate: time.Now(), Lines: []raildata.Line{raildatatest.Line("NE")}})
+	client := server.NewClient(t)
+
+	msgs, err := client.GetStationMsg(context.Background(), &raildata.GetStationMsgRequest{})
+	require.NoError(t, err)
+	assert.Len(t, msgs.Messages, 2)
+
+	code := raildata.StationCode("NP")
+	msgs, err = client.GetStationMsg(context.Background(), &raildata.GetStationMsgRequest{StationCode: &code})
+	require.NoError(t, err)
+	require.Len(t, msgs.Messages, 1)
+	assert.Equal(t, "Elevator out of service", msgs.Messages[0].Text)
+	assert.Equal(t, []raildata.Station{raildatatest.Station("NP")}, msgs.Messages[0].StationScope)
+
+	server.ClearMessages()
+	msgs, err = client.GetStationMsg(context.Background(), &raildata.GetStationMsgRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, msgs.Messages)
+}
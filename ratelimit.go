@@ -0,0 +1,239 @@
+package raildata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	rderrors "github.com/jtarrio/raildata/errors"
+	"github.com/rogpeppe/go-internal/lockedfile"
+)
+
+// RateLimit contains the parameters of a token bucket that limits the rate of calls to the RailData API.
+type RateLimit struct {
+	// Rate contains the number of calls per second allowed in the long run. It must be greater than zero.
+	Rate float64
+	// Burst contains the number of calls that can be made at once after a period of inactivity.
+	// Values lower than 1 are treated as 1.
+	Burst int
+}
+
+// RateLimitPolicy specifies the rate limits applied by a [RateLimiter].
+type RateLimitPolicy struct {
+	// Global contains the limit for all calls, regardless of the method, if any.
+	Global *RateLimit
+	// Methods contains the limit for each method. Methods that are not listed here only have the global limit.
+	Methods map[string]RateLimit
+	// FailFast makes calls that go over the limit fail immediately with a
+	// [github.com/jtarrio/raildata/errors.RateLimitedError] instead of waiting until they can be made.
+	FailFast bool
+}
+
+// TokenBucket contains the state of a token bucket.
+type TokenBucket struct {
+	// Tokens contains the number of tokens in the bucket. It can be negative if callers are waiting for tokens.
+	Tokens float64 `json:"tokens"`
+	// Updated contains the date/time when Tokens was computed. A zero value means a full bucket.
+	Updated time.Time `json:"updated"`
+}
+
+// RateLimitStore persists the state of the token buckets used by a [RateLimiter].
+//
+// Implementations must be safe for concurrent use.
+type RateLimitStore interface {
+	// Update atomically replaces the state of the named buckets with the values returned by the update function,
+	// which receives their current state in the same order. Buckets that were never stored have a zero value.
+	Update(names []string, update func(buckets []TokenBucket) []TokenBucket) error
+}
+
+// NewMemoryRateLimitStore returns a [RateLimitStore] that keeps the buckets in memory.
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{buckets: rateLimitBuckets{}}
+}
+
+type memoryRateLimitStore struct {
+	mutex   sync.Mutex
+	buckets rateLimitBuckets
+}
+
+func (r *memoryRateLimitStore) Update(names []string, update func(buckets []TokenBucket) []TokenBucket) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.buckets.update(names, update)
+	return nil
+}
+
+// NewFileRateLimitStore returns a [RateLimitStore] that keeps the buckets in a file.
+// The file is locked while it's being updated, so several processes can share a combined budget.
+func NewFileRateLimitStore(name string) RateLimitStore {
+	return &fileRateLimitStore{name: name}
+}
+
+type fileRateLimitStore struct {
+	name string
+}
+
+func (r *fileRateLimitStore) Update(names []string, update func(buckets []TokenBucket) []TokenBucket) error {
+	return lockedfile.Transform(r.name, func(old []byte) ([]byte, error) {
+		buckets := rateLimitBuckets{}
+		if len(old) > 0 {
+			if err := json.Unmarshal(old, &buckets); err != nil {
+				return nil, err
+			}
+		}
+		buckets.update(names, update)
+		return json.Marshal(buckets)
+	})
+}
+
+type rateLimitBuckets map[string]TokenBucket
+
+func (r rateLimitBuckets) update(names []string, update func(buckets []TokenBucket) []TokenBucket) {
+	buckets := make([]TokenBucket, len(names))
+	for i, name := range names {
+		buckets[i] = r[name]
+	}
+	for i, bucket := range update(buckets) {
+		r[names[i]] = bucket
+	}
+}
+
+// RateLimiter limits the rate of calls to the RailData API using token buckets.
+//
+// Add it to a client with the [WithRateLimiter] option. Several clients can share the same limiter;
+// if they are in different processes, create their limiters with the same store, for example
+// one created with [NewFileRateLimitStore].
+type RateLimiter struct {
+	policy RateLimitPolicy
+	store  RateLimitStore
+}
+
+// NewRateLimiter creates a [RateLimiter] with the given policy. If the store is nil, the buckets are kept in memory.
+func NewRateLimiter(policy RateLimitPolicy, store RateLimitStore) *RateLimiter {
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+	return &RateLimiter{policy: policy, store: store}
+}
+
+// WithRateLimiter makes the client limit the rate of its calls to the RailData API with the given limiter.
+//
+// The limits apply to every HTTP request, including retries and the requests to create tokens.
+// Calls served from the cache are not limited. [NewClient] returns an error if the limiter's policy
+// contains a limit whose rate is not greater than zero.
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(s *raildataClient) {
+		s.rateLimiter = limiter
+	}
+}
+
+// globalBucket contains the name of the bucket used for the global limit.
+const globalBucket = "*"
+
+// validate returns an error if the limiter's policy contains an invalid limit.
+func (r *RateLimiter) validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.policy.Global != nil && r.policy.Global.Rate <= 0 {
+		return fmt.Errorf("the global rate limit must be greater than zero, got %v", r.policy.Global.Rate)
+	}
+	for method, limit := range r.policy.Methods {
+		if limit.Rate <= 0 {
+			return fmt.Errorf("the rate limit for %s must be greater than zero, got %v", method, limit.Rate)
+		}
+	}
+	return nil
+}
+
+// wait takes a token for the given method, waiting until it is available or the context is cancelled.
+// In fail-fast mode, it returns a RateLimitedError instead of waiting.
+// If the context is cancelled, the token is returned so it can be used by other callers.
+func (r *RateLimiter) wait(ctx context.Context, method string) error {
+	if r == nil {
+		return nil
+	}
+	var names []string
+	var limits []RateLimit
+	if r.policy.Global != nil {
+		names = append(names, globalBucket)
+		limits = append(limits, *r.policy.Global)
+	}
+	if limit, found := r.policy.Methods[method]; found {
+		names = append(names, method)
+		limits = append(limits, limit)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	var delay time.Duration
+	now := time.Now()
+	err := r.store.Update(names, func(buckets []TokenBucket) []TokenBucket {
+		delay = 0
+		for i := range buckets {
+			buckets[i] = limits[i].refill(buckets[i], now)
+			if buckets[i].Tokens < 1 {
+				delay = max(delay, limits[i].delay(1-buckets[i].Tokens))
+			}
+		}
+		if delay > 0 && r.policy.FailFast {
+			return buckets
+		}
+		// In blocking mode the token is taken now, even if it makes the bucket negative,
+		// so callers are served in the order they arrived.
+		for i := range buckets {
+			buckets[i].Tokens--
+		}
+		return buckets
+	})
+	if err != nil {
+		return err
+	}
+	if delay <= 0 {
+		return nil
+	}
+	if r.policy.FailFast {
+		return rderrors.NewRateLimitedError(method, delay)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// The error is ignored because the caller is going to fail anyway.
+		_ = r.store.Update(names, func(buckets []TokenBucket) []TokenBucket {
+			now := time.Now()
+			for i := range buckets {
+				buckets[i] = limits[i].refill(buckets[i], now)
+				buckets[i].Tokens = min(limits[i].burst(), buckets[i].Tokens+1)
+			}
+			return buckets
+		})
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (l RateLimit) burst() float64 {
+	return float64(max(l.Burst, 1))
+}
+
+// refill returns the bucket with the tokens added since it was last updated.
+func (l RateLimit) refill(bucket TokenBucket, now time.Time) TokenBucket {
+	if bucket.Updated.IsZero() {
+		return TokenBucket{Tokens: l.burst(), Updated: now}
+	}
+	if elapsed := now.Sub(bucket.Updated); elapsed > 0 {
+		bucket.Tokens = min(l.burst(), bucket.Tokens+elapsed.Seconds()*l.Rate)
+		bucket.Updated = now
+	}
+	return bucket
+}
+
+// delay returns how long it takes to add the given number of tokens to the bucket.
+func (l RateLimit) delay(tokens float64) time.Duration {
+	return time.Duration(tokens / l.Rate * float64(time.Second))
+}
@@ -0,0 +1,148 @@
+package raildata_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCountingServer(t *testing.T, count *atomic.Int32) *httptest.Server {
+	handler := expectRequest(t, "getStationList").sendJson(`[]`)
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		count.Add(1)
+		handler.ServeHTTP(rw, req)
+	}))
+}
+
+func TestRateLimiterFailFast(t *testing.T) {
+	var count atomic.Int32
+	server := newCountingServer(t, &count)
+	defer server.Close()
+
+	limiter := raildata.NewRateLimiter(raildata.RateLimitPolicy{
+		Methods:  map[string]raildata.RateLimit{"getStationList": {Rate: 0.1, Burst: 2}},
+		FailFast: true,
+	}, nil)
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithRateLimiter(limiter))
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = client.GetStationList(context.Background())
+		require.NoError(t, err)
+	}
+	_, err = client.GetStationList(context.Background())
+	var lerr *errors.RateLimitedError
+	require.ErrorAs(t, err, &lerr)
+	assert.Equal(t, "getStationList", lerr.Method)
+	assert.InDelta(t, 10*time.Second, lerr.RetryAfter, float64(time.Second))
+	assert.Equal(t, "rate_limited", errors.Classify(err))
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestRateLimiterWaits(t *testing.T) {
+	var count atomic.Int32
+	server := newCountingServer(t, &count)
+	defer server.Close()
+
+	limiter := raildata.NewRateLimiter(raildata.RateLimitPolicy{Global: &raildata.RateLimit{Rate: 20, Burst: 1}}, nil)
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithRateLimiter(limiter))
+	require.NoError(t, err)
+
+	start := time.Now()
+	for range 3 {
+		_, err = client.GetStationList(context.Background())
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	assert.Equal(t, int32(3), count.Load())
+}
+
+func TestRateLimiterWaitIsCancelledWithContext(t *testing.T) {
+	var count atomic.Int32
+	server := newCountingServer(t, &count)
+	defer server.Close()
+
+	limiter := raildata.NewRateLimiter(raildata.RateLimitPolicy{Global: &raildata.RateLimit{Rate: 0.01, Burst: 1}}, nil)
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithRateLimiter(limiter))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.GetStationList(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), count.Load())
+}
+
+func TestRateLimiterReturnsTokenWhenCancelled(t *testing.T) {
+	var count atomic.Int32
+	server := newCountingServer(t, &count)
+	defer server.Close()
+
+	limiter := raildata.NewRateLimiter(raildata.RateLimitPolicy{Global: &raildata.RateLimit{Rate: 5, Burst: 1}}, nil)
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithRateLimiter(limiter))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.GetStationList(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The cancelled call gave its token back, so this call only waits for one token (200ms) instead of two.
+	start := time.Now()
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 300*time.Millisecond)
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestRateLimiterRejectsInvalidRate(t *testing.T) {
+	limiter := raildata.NewRateLimiter(raildata.RateLimitPolicy{Global: &raildata.RateLimit{Rate: 0, Burst: 1}}, nil)
+	_, err := raildata.NewClient(raildata.WithToken(testToken), raildata.WithRateLimiter(limiter))
+	assert.Error(t, err)
+
+	limiter = raildata.NewRateLimiter(raildata.RateLimitPolicy{Methods: map[string]raildata.RateLimit{"getStationList": {Rate: -1}}}, nil)
+	_, err = raildata.NewClient(raildata.WithToken(testToken), raildata.WithRateLimiter(limiter))
+	assert.Error(t, err)
+}
+
+func TestFileRateLimitStoreIsShared(t *testing.T) {
+	var count atomic.Int32
+	server := newCountingServer(t, &count)
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "ratelimit")
+	policy := raildata.RateLimitPolicy{Global: &raildata.RateLimit{Rate: 0.01, Burst: 3}, FailFast: true}
+	var clients []raildata.Client
+	for range 2 {
+		limiter := raildata.NewRateLimiter(policy, raildata.NewFileRateLimitStore(file))
+		client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithRateLimiter(limiter))
+		require.NoError(t, err)
+		clients = append(clients, client)
+	}
+
+	var failures int
+	for i := range 6 {
+		if _, err := clients[i%2].GetStationList(context.Background()); err != nil {
+			var lerr *errors.RateLimitedError
+			require.ErrorAs(t, err, &lerr)
+			failures++
+		}
+	}
+	assert.Equal(t, 3, failures)
+	assert.Equal(t, int32(3), count.Load())
+}
@@ -0,0 +1,189 @@
+package raildata
+
+import (
+	"context"
+
+	"github.com/jtarrio/raildata/api"
+	rderrors "github.com/jtarrio/raildata/errors"
+)
+
+// RawResponse contains a response from the RailData API in all the forms the client knows about.
+type RawResponse[A any, R any] struct {
+	// Response contains the enriched response, as returned by the [Client] methods.
+	Response *R
+	// Api contains the response decoded into the structures in the api package.
+	Api *A
+	// Json contains the raw JSON document received from the RailData server, or retrieved from the cache.
+	Json []byte
+}
+
+// RawClient has the same methods as [Client] and [RateLimitedMethods], but returns the
+// responses from the RailData API along with the enriched responses, so you can see exactly what the API said.
+type RawClient interface {
+	// IsValidToken returns whether the current token is valid. This method is rate-limited.
+	IsValidToken(context.Context) (*RawResponse[api.ValidTokenResponse, IsValidTokenResponse], error)
+	// GetStationList returns a list of all stations.
+	GetStationList(context.Context) (*RawResponse[[]api.GetStations, GetStationListResponse], error)
+	// GetStationMsg returns a list of messages and alerts.
+	GetStationMsg(context.Context, *GetStationMsgRequest) (*RawResponse[[]api.StationMsgs, GetStationMsgResponse], error)
+	// GetStationSchedule returns the schedule for the next 27 hours for one station. This method is rate-limited.
+	GetStationSchedule(context.Context, *GetStationScheduleRequest) (*RawResponse[[]api.DailyStationInfo, GetStationScheduleResponse], error)
+	// GetTrainSchedule returns the schedule for the next 19 trains departing from a station.
+	GetTrainSchedule(context.Context, *GetTrainScheduleRequest) (*RawResponse[api.StationInfo, GetTrainScheduleResponse], error)
+	// GetTrainSchedule19Records returns the schedule for the next 19 trains departing from a station, without their stops.
+	GetTrainSchedule19Records(context.Context, *GetTrainSchedule19RecordsRequest) (*RawResponse[api.StationInfo, GetTrainScheduleResponse], error)
+	// GetTrainStopList returns the list of stops for a train.
+	GetTrainStopList(context.Context, *GetTrainStopListRequest) (*RawResponse[api.Stops, GetTrainStopListResponse], error)
+	// GetVehicleData returns the position and status for all active trains.
+	GetVehicleData(context.Context) (*RawResponse[[]api.VehicleDataInfo, GetVehicleDataResponse], error)
+}
+
+func (s *raildataClient) Raw() RawClient {
+	return &rawClient{s: s}
+}
+
+type rawClient struct {
+	s *raildataClient
+}
+
+// enriched returns only the enriched response from a raw response.
+func enriched[A any, R any](raw *RawResponse[A, R], err error) (*R, error) {
+	if err != nil {
+		return nil, err
+	}
+	return raw.Response, nil
+}
+
+func (r *rawClient) IsValidToken(ctx context.Context) (*RawResponse[api.ValidTokenResponse, IsValidTokenResponse], error) {
+	output, raw, info, err := request(api.IsValidToken, r.s, ctx, &api.TokenRequest{})
+	if err != nil {
+		return nil, err
+	}
+	response, warnings, err := r.s.parseOptions.ParseValidTokenResponse(output)
+	if err != nil {
+		return nil, err
+	}
+	response.Info = info.withWarnings(warnings)
+	return &RawResponse[api.ValidTokenResponse, IsValidTokenResponse]{Response: response, Api: output, Json: raw}, nil
+}
+
+func (r *rawClient) GetStationList(ctx context.Context) (*RawResponse[[]api.GetStations, GetStationListResponse], error) {
+	output, raw, info, err := request(api.GetStationList, r.s, ctx, &api.TokenRequest{})
+	if err != nil {
+		return nil, err
+	}
+	response, warnings, err := r.s.parseOptions.ParseGetStationsList(*output)
+	if err != nil {
+		return nil, err
+	}
+	response.Info = info.withWarnings(warnings)
+	return &RawResponse[[]api.GetStations, GetStationListResponse]{Response: response, Api: output, Json: raw}, nil
+}
+
+func (r *rawClient) GetStationMsg(ctx context.Context, req *GetStationMsgRequest) (*RawResponse[[]api.StationMsgs, GetStationMsgResponse], error) {
+	input := &api.GetStationMsgRequest{}
+	if req.LineCode != nil {
+		input.Line = string(*req.LineCode)
+	}
+	if req.StationCode != nil {
+		input.Station = string(*req.StationCode)
+	}
+	output, raw, info, err := request(api.GetStationMSG, r.s, ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	response, warnings, err := r.s.parseOptions.ParseStationMsgsList(*output)
+	if err != nil {
+		return nil, err
+	}
+	response.Info = info.withWarnings(warnings)
+	return &RawResponse[[]api.StationMsgs, GetStationMsgResponse]{Response: response, Api: output, Json: raw}, nil
+}
+
+func (r *rawClient) GetStationSchedule(ctx context.Context, req *GetStationScheduleRequest) (*RawResponse[[]api.DailyStationInfo, GetStationScheduleResponse], error) {
+	input := &api.GetStationScheduleRequest{
+		Station: string(req.StationCode),
+	}
+	if req.NjtOnly {
+		input.NjtOnly = "true"
+	} else {
+		input.NjtOnly = "false"
+	}
+	output, raw, info, err := request(api.GetStationSchedule, r.s, ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	response, warnings, err := r.s.parseOptions.ParseDailyStationInfoList(*output)
+	if err != nil {
+		return nil, err
+	}
+	response.Info = info.withWarnings(warnings)
+	return &RawResponse[[]api.DailyStationInfo, GetStationScheduleResponse]{Response: response, Api: output, Json: raw}, nil
+}
+
+func (r *rawClient) GetTrainSchedule(ctx context.Context, req *GetTrainScheduleRequest) (*RawResponse[api.StationInfo, GetTrainScheduleResponse], error) {
+	input := &api.GetTrainScheduleRequest{
+		Station: string(req.StationCode),
+	}
+	output, raw, info, err := request(api.GetTrainSchedule, r.s, ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	response, warnings, err := r.s.parseOptions.ParseStationInfo(output)
+	if err != nil {
+		return nil, err
+	}
+	response.Info = info.withWarnings(warnings)
+	return &RawResponse[api.StationInfo, GetTrainScheduleResponse]{Response: response, Api: output, Json: raw}, nil
+}
+
+func (r *rawClient) GetTrainSchedule19Records(ctx context.Context, req *GetTrainSchedule19RecordsRequest) (*RawResponse[api.StationInfo, GetTrainScheduleResponse], error) {
+	input := &api.GetTrainSchedule19RecRequest{
+		Station: string(req.StationCode),
+	}
+	if req.LineCode != nil {
+		input.Line = string(*req.LineCode)
+	}
+	output, raw, info, err := request(api.GetTrainSchedule19Rec, r.s, ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	response, warnings, err := r.s.parseOptions.ParseStationInfo(output)
+	if err != nil {
+		return nil, err
+	}
+	response.Info = info.withWarnings(warnings)
+	return &RawResponse[api.StationInfo, GetTrainScheduleResponse]{Response: response, Api: output, Json: raw}, nil
+}
+
+func (r *rawClient) GetTrainStopList(ctx context.Context, req *GetTrainStopListRequest) (*RawResponse[api.Stops, GetTrainStopListResponse], error) {
+	input := &api.GetTrainStopListRequest{
+		Train: req.TrainId,
+	}
+	output, raw, info, err := request(api.GetTrainStopList, r.s, ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	response, warnings, err := r.s.parseOptions.ParseStops(output)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, rderrors.NewTrainNotFoundError(req.TrainId)
+	}
+	response.Info = info.withWarnings(warnings)
+	return &RawResponse[api.Stops, GetTrainStopListResponse]{Response: response, Api: output, Json: raw}, nil
+}
+
+func (r *rawClient) GetVehicleData(ctx context.Context) (*RawResponse[[]api.VehicleDataInfo, GetVehicleDataResponse], error) {
+	output, raw, info, err := request(api.GetVehicleData, r.s, ctx, &api.TokenRequest{})
+	if err != nil {
+		return nil, err
+	}
+	response, warnings, err := r.s.parseOptions.ParseVehicleDataInfoList(*output)
+	if err != nil {
+		return nil, err
+	}
+	response.Info = info.withWarnings(warnings)
+	return &RawResponse[[]api.VehicleDataInfo, GetVehicleDataResponse]{Response: response, Api: output, Json: raw}, nil
+}
@@ -0,0 +1,63 @@
+package raildata_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawClient(t *testing.T) {
+	body := `[
+  {
+    "STATION_2CHAR": "XZ",
+    "STATIONNAME": "Some New Station",
+    "STATION_14CHAR": "New Station"
+  }
+]`
+	server := httptest.NewServer(expectRequest(t, "getStationList").sendJson(body))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken))
+	require.NoError(t, err)
+
+	actual, err := client.Raw().GetStationList(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, body, string(actual.Json))
+	require.Len(t, *actual.Api, 1)
+	assert.Equal(t, "XZ", (*actual.Api)[0].STATION_2CHAR)
+	require.Len(t, actual.Response.Stations, 1)
+	assert.Equal(t, raildata.StationCode("XZ"), actual.Response.Stations[0].Code)
+}
+
+func TestRawClientTrainNotFound(t *testing.T) {
+	body := `{"TRAIN_ID": null, "LINECODE": null, "STOPS": null}`
+	server := httptest.NewServer(expectRequest(t, "getTrainStopList", "train", "1234").sendJson(body))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken))
+	require.NoError(t, err)
+
+	actual, err := client.Raw().GetTrainStopList(context.Background(), &raildata.GetTrainStopListRequest{TrainId: "1234"})
+	var notFound *errors.TrainNotFoundError
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, "1234", notFound.TrainId)
+	assert.Nil(t, actual)
+}
+
+func TestRawClientFromCache(t *testing.T) {
+	body := `[]`
+	server := httptest.NewServer(expectRequest(t, "getVehicleData").sendJson(body))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithCache(raildata.NewMemoryCache(10)))
+	require.NoError(t, err)
+
+	_, err = client.GetVehicleData(context.Background())
+	require.NoError(t, err)
+	actual, err := client.Raw().GetVehicleData(context.Background())
+	require.NoError(t, err)
+	assert.True(t, actual.Response.Info.Cached)
+	assert.Equal(t, body, string(actual.Json))
+}
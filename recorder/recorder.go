@@ -0,0 +1,263 @@
+// Package recorder provides an HTTP transport that records RailData API traffic to a directory
+// and replays it later, so you can reproduce problems and write tests without access to the RailData server.
+//
+// Tokens and passwords are redacted before the traffic is written to disk.
+//
+// Example:
+//
+//	rec, err := recorder.New("/path/to/cassettes", recorder.ModeRecord, nil)
+//	if err != nil { return err }
+//	client, err := raildata.NewClient(raildata.WithHttpClient(rec.Client()), ...)
+//
+// Later, create the recorder with [ModeReplay] to serve the recorded responses.
+package recorder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode specifies whether a [Recorder] records or replays traffic.
+type Mode int
+
+const (
+	ModeRecord Mode = iota // send requests to the server and record the responses.
+	ModeReplay             // serve the recorded responses without contacting the server.
+)
+
+// Redacted is the value that replaces tokens and passwords in the recorded traffic.
+const Redacted = "REDACTED"
+
+// Interaction contains a recorded request and its response. Each interaction is stored in its own file.
+type Interaction struct {
+	// Method contains the name of the RailData API method.
+	Method string `json:"method"`
+	// Fields contains the request's form fields, with tokens and passwords redacted.
+	Fields map[string]string `json:"fields"`
+	// StatusCode contains the response's HTTP status code.
+	StatusCode int `json:"statusCode"`
+	// Header contains the response's HTTP headers.
+	Header http.Header `json:"header,omitempty"`
+	// Body contains the response body, with tokens redacted.
+	Body string `json:"body"`
+	// RecordedAt contains the date/time the interaction was recorded.
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// Recorder is an [http.RoundTripper] that records or replays RailData API traffic.
+//
+// Interactions are matched by method name and request fields, ignoring tokens and passwords.
+// When the same request is made several times, the recorded responses are replayed in order;
+// when they run out, the last one is repeated.
+type Recorder struct {
+	dir       string
+	mode      Mode
+	transport http.RoundTripper
+	mutex     sync.Mutex
+	counts    map[string]int
+}
+
+// New creates a [Recorder] that stores interactions in the given directory.
+// In [ModeRecord], requests are sent with the given transport, or [http.DefaultTransport] if it's nil.
+func New(dir string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if mode == ModeRecord {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{dir: dir, mode: mode, transport: transport, counts: map[string]int{}}, nil
+}
+
+// Client returns an [http.Client] that uses this recorder, suitable for [raildata.WithHttpClient].
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := parseFields(req.Header.Get("content-type"), body)
+	if err != nil {
+		return nil, err
+	}
+	interaction := &Interaction{Method: path.Base(req.URL.Path), Fields: redactFields(fields)}
+	key := interactionKey(interaction)
+
+	r.mutex.Lock()
+	seq := r.counts[key]
+	r.counts[key] = seq + 1
+	r.mutex.Unlock()
+
+	if r.mode == ModeReplay {
+		return r.replay(req, key, seq)
+	}
+	return r.record(req, body, interaction, key, seq)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte, interaction *Interaction, key string, seq int) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction.StatusCode = resp.StatusCode
+	interaction.Header = resp.Header.Clone()
+	interaction.Body = redactBody(respBody)
+	interaction.RecordedAt = time.Now()
+	b, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(r.fileName(key, seq), b, 0o644); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, key string, seq int) (*http.Response, error) {
+	var b []byte
+	var err error
+	for ; seq >= 0; seq-- {
+		b, err = os.ReadFile(r.fileName(key, seq))
+		if !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("no recorded interaction for %s: %w", req.URL.Path, err)
+	}
+	var interaction Interaction
+	if err := json.Unmarshal(b, &interaction); err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+		StatusCode:    interaction.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Header,
+		Body:          io.NopCloser(strings.NewReader(interaction.Body)),
+		ContentLength: int64(len(interaction.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Recorder) fileName(key string, seq int) string {
+	return filepath.Join(r.dir, fmt.Sprintf("%s-%03d.json", key, seq))
+}
+
+// LoadInteractions reads all the interactions recorded in the given directory.
+func LoadInteractions(dir string) ([]Interaction, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+	var out []Interaction
+	for _, name := range names {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(b, &interaction); err != nil {
+			return nil, fmt.Errorf("could not read interaction from %s: %w", name, err)
+		}
+		out = append(out, interaction)
+	}
+	return out, nil
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
+
+func parseFields(contentType string, body []byte) (map[string]string, error) {
+	fields := map[string]string{}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return fields, nil
+	}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return fields, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		value, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		fields[part.FormName()] = string(value)
+	}
+}
+
+var redactedFields = []string{"token", "password"}
+
+func redactFields(fields map[string]string) map[string]string {
+	for _, name := range redactedFields {
+		if _, found := fields[name]; found {
+			fields[name] = Redacted
+		}
+	}
+	return fields
+}
+
+func redactBody(body []byte) string {
+	var tokenResponse map[string]any
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return string(body)
+	}
+	if _, found := tokenResponse["UserToken"]; !found {
+		return string(body)
+	}
+	tokenResponse["UserToken"] = Redacted
+	b, err := json.Marshal(tokenResponse)
+	if err != nil {
+		return string(body)
+	}
+	return string(b)
+}
+
+func interactionKey(interaction *Interaction) string {
+	b, _ := json.Marshal(interaction.Fields)
+	sum := sha256.Sum256(b)
+	return interaction.Method + "-" + hex.EncodeToString(sum[:4])
+}
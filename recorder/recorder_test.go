@@ -0,0 +1,84 @@
+package recorder_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/recorder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseMultipartForm(5000000))
+		switch req.URL.Path {
+		case "/getStationList":
+			if req.Form.Get("token") == "secret-token" {
+				rw.Write([]byte(`[{"STATION_2CHAR": "AM", "STATIONNAME": "Aberdeen-Matawan", "STATION_14CHAR": "Matawan"}]`))
+			} else {
+				rw.WriteHeader(500)
+				rw.Write([]byte(`{"errorMessage": "Invalid token."}`))
+			}
+		case "/getToken":
+			rw.Write([]byte(`{"Authenticated": "True", "UserToken": "secret-token"}`))
+		}
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	rec, err := recorder.New(dir, recorder.ModeRecord, nil)
+	require.NoError(t, err)
+	client, err := raildata.NewClient(
+		raildata.WithApiBase(*u),
+		raildata.WithHttpClient(rec.Client()),
+		raildata.WithToken("old-token"),
+		raildata.WithCredentials("the-user", "secret-password"))
+	require.NoError(t, err)
+	expected, err := client.GetStationList(context.Background())
+	require.NoError(t, err)
+
+	interactions, err := recorder.LoadInteractions(dir)
+	require.NoError(t, err)
+	assert.Len(t, interactions, 3)
+	names, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	for _, name := range names {
+		b, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.False(t, strings.Contains(string(b), "secret"), "file %s contains a secret", name)
+	}
+
+	replayer, err := recorder.New(dir, recorder.ModeReplay, nil)
+	require.NoError(t, err)
+	client, err = raildata.NewClient(
+		raildata.WithApiBase(*u),
+		raildata.WithHttpClient(replayer.Client()),
+		raildata.WithToken("another-token"),
+		raildata.WithCredentials("the-user", "another-password"))
+	require.NoError(t, err)
+	server.Close()
+
+	actual, err := client.GetStationList(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, expected.Stations, actual.Stations)
+}
+
+func TestReplayWithoutRecording(t *testing.T) {
+	replayer, err := recorder.New(t.TempDir(), recorder.ModeReplay, nil)
+	require.NoError(t, err)
+	client, err := raildata.NewClient(raildata.WithHttpClient(replayer.Client()), raildata.WithToken("token"))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	assert.Error(t, err)
+}
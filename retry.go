@@ -0,0 +1,110 @@
+package raildata
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jtarrio/raildata/api"
+	rderrors "github.com/jtarrio/raildata/errors"
+)
+
+// RetryPolicy specifies how the client retries requests that failed because of a transient error,
+// such as a network error or a 5xx response from the server.
+//
+// Requests are never retried for methods that have a daily usage limit, or for the method that creates tokens,
+// so retries can't use up the daily quota.
+type RetryPolicy struct {
+	// MaxAttempts contains the maximum number of attempts, including the first one.
+	// A value of 0 or 1 disables retries.
+	MaxAttempts int
+	// InitialBackoff contains how long to wait before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff contains the maximum time to wait between two attempts.
+	MaxBackoff time.Duration
+	// Multiplier contains the factor by which the wait time increases after each retry.
+	// Values lower than 1 are treated as 1.
+	Multiplier float64
+	// Jitter contains the fraction of the wait time, between 0 and 1, that is randomized
+	// so that many clients don't retry at the same time.
+	Jitter float64
+}
+
+// DefaultRetryPolicy contains a retry policy that is suitable for most applications.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// WithRetryPolicy sets the policy used to retry failed requests. By default, requests are not retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(s *raildataClient) {
+		s.retryPolicy = policy
+	}
+}
+
+// WithMethodRetryPolicy sets the policy used to retry failed requests for the given method,
+// overriding the policy set with [WithRetryPolicy].
+func WithMethodRetryPolicy(method string, policy RetryPolicy) Option {
+	return func(s *raildataClient) {
+		if s.methodRetryPolicies == nil {
+			s.methodRetryPolicies = map[string]RetryPolicy{}
+		}
+		s.methodRetryPolicies[method] = policy
+	}
+}
+
+func (s *raildataClient) getRetryPolicy(method string) RetryPolicy {
+	if method == api.GetToken.Name || s.quota.limit(method) > 0 {
+		return RetryPolicy{}
+	}
+	if policy, found := s.methodRetryPolicies[method]; found {
+		return policy
+	}
+	return s.retryPolicy
+}
+
+// retry calls the function until it succeeds, returns a non-transient error, or the policy's attempts are exhausted.
+func (s *raildataClient) retry(ctx context.Context, method string, call func() ([]byte, error)) ([]byte, error) {
+	policy := s.getRetryPolicy(method)
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		raw, err := call()
+		if err == nil || attempt >= policy.MaxAttempts || !isTransient(ctx, err) {
+			return raw, err
+		}
+		wait := jitter(backoff, policy.Jitter)
+		spanFromContext(ctx).retried(attempt+1, err)
+		s.logger.Info("retrying RailData API call", "method", method, "attempt", attempt+1, "backoff", wait, "error", err.Error())
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(wait):
+		}
+		backoff = time.Duration(float64(backoff) * max(1, policy.Multiplier))
+		if policy.MaxBackoff > 0 {
+			backoff = min(backoff, policy.MaxBackoff)
+		}
+	}
+}
+
+func isTransient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var herr *rderrors.HttpStatusError
+	if errors.As(err, &herr) {
+		return herr.Temporary()
+	}
+	var terr *rderrors.TransportError
+	return errors.As(err, &terr)
+}
+
+func jitter(d time.Duration, fraction float64) time.Duration {
+	fraction = min(max(fraction, 0), 1)
+	return time.Duration(float64(d) * (1 - fraction + 2*fraction*rand.Float64()))
+}
@@ -0,0 +1,105 @@
+package raildata_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRetryPolicy = raildata.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+func TestRetryTransientErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls < 3 {
+			expectRequest(t, "getStationList").sendResponse(503, "Service Unavailable").ServeHTTP(rw, req)
+		} else {
+			expectRequest(t, "getStationList").sendJson(`[]`).ServeHTTP(rw, req)
+		}
+	}))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithRetryPolicy(testRetryPolicy))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		expectRequest(t, "getStationList").sendResponse(503, "Service Unavailable").ServeHTTP(rw, req)
+	}))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithRetryPolicy(testRetryPolicy))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	var herr *errors.HttpStatusError
+	assert.ErrorAs(t, err, &herr)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryDoesNotRetryApiErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		expectRequest(t, "getStationList").sendError("some error message").ServeHTTP(rw, req)
+	}))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithRetryPolicy(testRetryPolicy))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	var rderr *errors.RailDataError
+	assert.ErrorAs(t, err, &rderr)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryDoesNotRetryRateLimitedMethods(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		expectRequest(t, "isValidToken").sendResponse(503, "Service Unavailable").ServeHTTP(rw, req)
+	}))
+
+	client, err := raildata.NewClient(
+		withServerUrl(t, server),
+		raildata.WithToken(testToken),
+		raildata.WithRetryPolicy(testRetryPolicy),
+		raildata.WithMethodRetryPolicy("isValidToken", testRetryPolicy))
+	require.NoError(t, err)
+
+	_, err = client.RateLimitedMethods().IsValidToken(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMethodRetryPolicyOverridesDefault(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		expectRequest(t, "getStationList").sendResponse(503, "Service Unavailable").ServeHTTP(rw, req)
+	}))
+
+	client, err := raildata.NewClient(
+		withServerUrl(t, server),
+		raildata.WithToken(testToken),
+		raildata.WithRetryPolicy(testRetryPolicy),
+		raildata.WithMethodRetryPolicy("getStationList", raildata.RetryPolicy{}))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,363 @@
+package raildata
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SchemaDriftKind represents a kind of difference between a RailData API response and the structures in the api package.
+type SchemaDriftKind int
+
+const (
+	SchemaDriftUnknownField SchemaDriftKind = iota // the response contains a field that is not in the api structure.
+	SchemaDriftTypeChanged                         // the field's JSON type is not the one expected by the api structure.
+	SchemaDriftAlwaysEmpty                         // the field has been missing or empty in all the responses seen.
+)
+
+func (k SchemaDriftKind) String() string {
+	switch k {
+	case SchemaDriftUnknownField:
+		return "unknown field"
+	case SchemaDriftTypeChanged:
+		return "type changed"
+	case SchemaDriftAlwaysEmpty:
+		return "always empty"
+	default:
+		return "unknown"
+	}
+}
+
+// SchemaDrift contains a difference between the responses for a RailData API method and the structures in the api package.
+type SchemaDrift struct {
+	// Kind contains the kind of difference.
+	Kind SchemaDriftKind
+	// Field contains the path to the field, for example "ITEMS[].STOPS[].TIME".
+	Field string
+	// Expected contains the JSON type expected by the api structure. It is empty for unknown fields.
+	Expected string
+	// Actual contains the JSON type found in the response. It is empty for fields that are always empty.
+	Actual string
+	// Example contains an example of the field's value, possibly truncated. It is empty for fields that are always empty.
+	Example string
+	// Count contains the number of responses where this difference was seen.
+	Count int
+	// FirstSeen contains the date/time this difference was first seen.
+	FirstSeen time.Time
+	// LastSeen contains the date/time this difference was last seen.
+	LastSeen time.Time
+}
+
+// SchemaReport contains the differences found in the responses for a RailData API method.
+type SchemaReport struct {
+	// Method contains the name of the RailData API method.
+	Method string
+	// Responses contains the number of responses examined.
+	Responses int
+	// Drift contains the differences found, sorted by kind and field.
+	Drift []SchemaDrift
+}
+
+// SchemaDriftListener is the type of a function that is called the first time an unknown field
+// or a type change is found in the responses for a RailData API method.
+type SchemaDriftListener func(method string, drift SchemaDrift)
+
+// SchemaMonitor compares the responses received from the RailData API with the structures in the api package
+// and keeps track of the differences, so you can find out when NJ Transit adds, renames, or changes fields.
+//
+// Add it to a client with the [WithSchemaMonitor] option. Cached responses are not examined again.
+type SchemaMonitor struct {
+	mutex    sync.Mutex
+	listener SchemaDriftListener
+	methods  map[string]*methodSchema
+}
+
+// NewSchemaMonitor creates a [SchemaMonitor]. If the listener is not nil, it is called whenever a new
+// unknown field or type change is found. Fields that are always empty are only listed in the [SchemaMonitor.Report].
+func NewSchemaMonitor(listener SchemaDriftListener) *SchemaMonitor {
+	return &SchemaMonitor{listener: listener, methods: map[string]*methodSchema{}}
+}
+
+// WithSchemaMonitor makes the client check every response from the RailData API with the given monitor.
+func WithSchemaMonitor(monitor *SchemaMonitor) Option {
+	return func(s *raildataClient) {
+		s.schemaMonitor = monitor
+	}
+}
+
+// Report returns the differences found so far, one entry per method, sorted by method name.
+func (m *SchemaMonitor) Report() []SchemaReport {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var out []SchemaReport
+	for _, method := range slices.Sorted(maps.Keys(m.methods)) {
+		schema := m.methods[method]
+		report := SchemaReport{Method: method, Responses: schema.responses}
+		for _, drift := range schema.drift {
+			report.Drift = append(report.Drift, *drift)
+		}
+		for field, stats := range schema.fields {
+			if stats.nonEmpty == 0 {
+				report.Drift = append(report.Drift, SchemaDrift{
+					Kind:      SchemaDriftAlwaysEmpty,
+					Field:     field,
+					Count:     stats.seen,
+					FirstSeen: stats.firstSeen,
+					LastSeen:  stats.lastSeen,
+				})
+			}
+		}
+		slices.SortFunc(report.Drift, func(a, b SchemaDrift) int {
+			if a.Kind != b.Kind {
+				return int(a.Kind) - int(b.Kind)
+			}
+			return strings.Compare(a.Field, b.Field)
+		})
+		out = append(out, report)
+	}
+	return out
+}
+
+type methodSchema struct {
+	responses int
+	fields    map[string]*fieldStats
+	drift     map[driftKey]*SchemaDrift
+}
+
+type fieldStats struct {
+	seen      int
+	nonEmpty  int
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+type driftKey struct {
+	kind  SchemaDriftKind
+	field string
+}
+
+// observe examines a response for the given method, which is expected to decode into the given type.
+func (m *SchemaMonitor) observe(method string, raw []byte, t reflect.Type) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return
+	}
+	obs := &schemaObservation{fields: map[string]bool{}, drift: map[driftKey]*SchemaDrift{}}
+	obs.walk("", value, t)
+
+	now := time.Now()
+	var found []SchemaDrift
+	m.mutex.Lock()
+	schema, ok := m.methods[method]
+	if !ok {
+		schema = &methodSchema{fields: map[string]*fieldStats{}, drift: map[driftKey]*SchemaDrift{}}
+		m.methods[method] = schema
+	}
+	schema.responses++
+	for field, nonEmpty := range obs.fields {
+		stats, ok := schema.fields[field]
+		if !ok {
+			stats = &fieldStats{firstSeen: now}
+			schema.fields[field] = stats
+		}
+		stats.seen++
+		stats.lastSeen = now
+		if nonEmpty {
+			stats.nonEmpty++
+		}
+	}
+	for key, drift := range obs.drift {
+		existing, ok := schema.drift[key]
+		if !ok {
+			drift.Count = 1
+			drift.FirstSeen = now
+			drift.LastSeen = now
+			schema.drift[key] = drift
+			found = append(found, *drift)
+			continue
+		}
+		existing.Count++
+		existing.LastSeen = now
+	}
+	m.mutex.Unlock()
+
+	if m.listener != nil {
+		for _, drift := range found {
+			m.listener(method, drift)
+		}
+	}
+}
+
+// schemaObservation contains the fields and differences found in a single response.
+type schemaObservation struct {
+	fields map[string]bool
+	drift  map[driftKey]*SchemaDrift
+}
+
+func (o *schemaObservation) walk(path string, value any, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if value == nil {
+			o.record(path, false)
+			return
+		}
+		object, ok := value.(map[string]any)
+		if !ok {
+			o.typeChanged(path, t, value)
+			return
+		}
+		o.record(path, true)
+		fields := jsonFields(t)
+		for name, fieldValue := range object {
+			if !slices.ContainsFunc(fields, func(f jsonField) bool { return strings.EqualFold(f.name, name) }) {
+				o.add(SchemaDriftUnknownField, joinPath(path, name), "", fieldValue)
+			}
+		}
+		for _, field := range fields {
+			o.walk(joinPath(path, field.name), lookupField(object, field.name), field.t)
+		}
+	case reflect.Slice:
+		if value == nil {
+			o.record(path, false)
+			return
+		}
+		array, ok := value.([]any)
+		if !ok {
+			o.typeChanged(path, t, value)
+			return
+		}
+		o.record(path, len(array) > 0)
+		for _, element := range array {
+			o.walk(path+"[]", element, t.Elem())
+		}
+	default:
+		if value == nil {
+			o.record(path, false)
+			return
+		}
+		if jsonType(value) != expectedJsonType(t) {
+			o.typeChanged(path, t, value)
+			return
+		}
+		str, isString := value.(string)
+		o.record(path, !isString || len(strings.TrimSpace(str)) > 0)
+	}
+}
+
+func (o *schemaObservation) record(path string, nonEmpty bool) {
+	if len(path) == 0 {
+		return
+	}
+	o.fields[path] = o.fields[path] || nonEmpty
+}
+
+func (o *schemaObservation) typeChanged(path string, t reflect.Type, value any) {
+	o.add(SchemaDriftTypeChanged, path, expectedJsonType(t), value)
+}
+
+func (o *schemaObservation) add(kind SchemaDriftKind, path string, expected string, value any) {
+	key := driftKey{kind: kind, field: path}
+	if _, found := o.drift[key]; found {
+		return
+	}
+	o.drift[key] = &SchemaDrift{Kind: kind, Field: path, Expected: expected, Actual: jsonType(value), Example: jsonExample(value)}
+}
+
+type jsonField struct {
+	name string
+	t    reflect.Type
+}
+
+func jsonFields(t reflect.Type) []jsonField {
+	var out []jsonField
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		out = append(out, jsonField{name: name, t: f.Type})
+	}
+	return out
+}
+
+// lookupField returns the value for the given key, matching it case-insensitively like encoding/json does.
+func lookupField(object map[string]any, name string) any {
+	if value, found := object[name]; found {
+		return value
+	}
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return nil
+}
+
+func joinPath(path string, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
+
+func expectedJsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	default:
+		return "number"
+	}
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	default:
+		return "number"
+	}
+}
+
+const maxSchemaExample = 64
+
+func jsonExample(value any) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	if len(b) > maxSchemaExample {
+		return string(b[:maxSchemaExample]) + "..."
+	}
+	return string(b)
+}
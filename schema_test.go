@@ -0,0 +1,139 @@
+package raildata_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jtarrio/raildata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaMonitorFindsUnknownFieldsAndTypeChanges(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getVehicleData").sendJson(`[
+  {
+    "ID": "3887",
+    "TRAIN_LINE": "Northeast Corridor Line",
+    "DIRECTION": "Eastbound",
+    "ICS_TRACK_CKT": "",
+    "LAST_MODIFIED": "17-Jan-2025 02:40:00 PM",
+    "SCHED_DEP_TIME": "17-Jan-2025 02:40:00 PM",
+    "SEC_LATE": 120,
+    "NEXT_STOP": "New York Penn Station",
+    "LONGITUDE": "-74.5",
+    "LATITUDE": "40.1",
+    "CAR_COUNT": "10"
+  }
+]`))
+
+	var found []raildata.SchemaDrift
+	monitor := raildata.NewSchemaMonitor(func(method string, drift raildata.SchemaDrift) {
+		assert.Equal(t, "getVehicleData", method)
+		found = append(found, drift)
+	})
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithSchemaMonitor(monitor))
+	require.NoError(t, err)
+
+	// The type change makes decoding fail, but the monitor still examines the response.
+	_, err = client.GetVehicleData(context.Background())
+	assert.Error(t, err)
+	_, err = client.GetVehicleData(context.Background())
+	assert.Error(t, err)
+
+	require.Len(t, found, 2)
+	report := monitor.Report()
+	require.Len(t, report, 1)
+	assert.Equal(t, "getVehicleData", report[0].Method)
+	assert.Equal(t, 2, report[0].Responses)
+	require.Len(t, report[0].Drift, 3)
+
+	unknown := report[0].Drift[0]
+	assert.Equal(t, raildata.SchemaDriftUnknownField, unknown.Kind)
+	assert.Equal(t, "[].CAR_COUNT", unknown.Field)
+	assert.Equal(t, "string", unknown.Actual)
+	assert.Equal(t, `"10"`, unknown.Example)
+	assert.Equal(t, 2, unknown.Count)
+
+	changed := report[0].Drift[1]
+	assert.Equal(t, raildata.SchemaDriftTypeChanged, changed.Kind)
+	assert.Equal(t, "[].SEC_LATE", changed.Field)
+	assert.Equal(t, "string", changed.Expected)
+	assert.Equal(t, "number", changed.Actual)
+	assert.Equal(t, "120", changed.Example)
+
+	empty := report[0].Drift[2]
+	assert.Equal(t, raildata.SchemaDriftAlwaysEmpty, empty.Kind)
+	assert.Equal(t, "[].ICS_TRACK_CKT", empty.Field)
+	assert.Equal(t, 2, empty.Count)
+}
+
+func TestSchemaMonitorFindsAlwaysEmptyFields(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getStationMSG").sendJson(`[
+  {
+    "MSG_TYPE": "banner",
+    "MSG_TEXT": "Text of the message.",
+    "MSG_PUBDATE": "1/17/2025 2:40:00 PM",
+    "MSG_ID": "123",
+    "MSG_AGENCY": "NJT",
+    "MSG_SOURCE": " ",
+    "MSG_STATION_SCOPE": "*Newark Penn Station",
+    "MSG_LINE_SCOPE": "*Montclair-Boonton Line"
+  },
+  {
+    "MSG_TYPE": "banner",
+    "MSG_TEXT": "Another message.",
+    "MSG_PUBDATE": "1/17/2025 2:40:00 PM",
+    "MSG_ID": "",
+    "MSG_AGENCY": "",
+    "MSG_SOURCE": null,
+    "MSG_STATION_SCOPE": "",
+    "MSG_LINE_SCOPE": "",
+    "MSG_PUBDATE_UTC": "1/17/2025 7:40:00 PM"
+  }
+]`))
+
+	var found []raildata.SchemaDrift
+	monitor := raildata.NewSchemaMonitor(func(method string, drift raildata.SchemaDrift) {
+		found = append(found, drift)
+	})
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithSchemaMonitor(monitor))
+	require.NoError(t, err)
+
+	_, err = client.GetStationMsg(context.Background(), &raildata.GetStationMsgRequest{})
+	require.NoError(t, err)
+
+	assert.Empty(t, found)
+	report := monitor.Report()
+	require.Len(t, report, 1)
+	assert.Equal(t, []raildata.SchemaDrift{
+		{Kind: raildata.SchemaDriftAlwaysEmpty, Field: "[].MSG_SOURCE", Count: 1, FirstSeen: report[0].Drift[0].FirstSeen, LastSeen: report[0].Drift[0].LastSeen},
+	}, report[0].Drift)
+}
+
+func TestSchemaMonitorFindsNullObjects(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getTrainSchedule").sendJson(`{
+  "STATION_2CHAR": "NY",
+  "STATIONNAME": "New York",
+  "STATIONMSGS": [null],
+  "ITEMS": []
+}`))
+
+	monitor := raildata.NewSchemaMonitor(nil)
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithSchemaMonitor(monitor))
+	require.NoError(t, err)
+
+	_, err = client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+	require.NoError(t, err)
+
+	report := monitor.Report()
+	require.Len(t, report, 1)
+	var empty []string
+	for _, drift := range report[0].Drift {
+		if drift.Kind == raildata.SchemaDriftAlwaysEmpty {
+			empty = append(empty, drift.Field)
+		}
+	}
+	assert.Contains(t, empty, "STATIONMSGS[]")
+	assert.NotContains(t, empty, "STATIONMSGS")
+}
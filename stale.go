@@ -0,0 +1,34 @@
+package raildata
+
+import (
+	"time"
+)
+
+// DefaultStaleEntries contains the number of responses kept for [WithStaleFallback] when the client has no cache.
+const DefaultStaleEntries = 1000
+
+// WithStaleFallback makes the client return the last good response for a method and request when a call
+// to the RailData API fails with an error that may go away by itself, such as a transport error or
+// an HTTP 5xx status without an error message, as long as that response is not older than maxStaleness.
+// Other errors, such as rejected credentials or an exceeded daily limit, are returned to the caller.
+//
+// Stale responses have the Stale and Cached fields set in their Info field, and the Age field says how old they are.
+// The responses are kept in the cache set with [WithCache], even for methods that are not cached otherwise;
+// if the client has no cache, they are kept in memory, up to [DefaultStaleEntries] of them.
+// Calls that fail because their context was cancelled, and calls to IsValidToken, don't return stale responses.
+func WithStaleFallback(maxStaleness time.Duration) Option {
+	return func(s *raildataClient) {
+		s.maxStaleness = maxStaleness
+	}
+}
+
+// staleCache returns the cache where responses for the stale fallback are kept, or nil if it's disabled.
+func (s *raildataClient) staleCache() Cache {
+	if s.maxStaleness <= 0 {
+		return nil
+	}
+	if s.cache != nil {
+		return s.cache
+	}
+	return s.staleEntries
+}
@@ -0,0 +1,134 @@
+package raildata_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/jtarrio/raildata/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFailingServer returns a server that returns a schedule for the first request and fails afterwards.
+func newFailingServer(t *testing.T) *httptest.Server {
+	return newServerFailingWith(t, expectRequest(t, "getTrainSchedule").sendResponse(503, ""))
+}
+
+// newServerFailingWith returns a server that returns a schedule for the first request and
+// serves the rest with the given handler.
+func newServerFailingWith(t *testing.T, failure http.HandlerFunc) *httptest.Server {
+	var count atomic.Int32
+	good := expectRequest(t, "getTrainSchedule", "station", "NY").sendJson(`{"STATION_2CHAR": "NY", "STATIONNAME": "New York", "ITEMS": []}`)
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if count.Add(1) == 1 {
+			good.ServeHTTP(rw, req)
+		} else {
+			failure.ServeHTTP(rw, req)
+		}
+	}))
+}
+
+func TestStaleFallback(t *testing.T) {
+	server := newFailingServer(t)
+	defer server.Close()
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithStaleFallback(time.Minute))
+	require.NoError(t, err)
+
+	fresh, err := client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+	require.NoError(t, err)
+	assert.False(t, fresh.Info.Stale)
+
+	stale, err := client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+	require.NoError(t, err)
+	assert.True(t, stale.Info.Stale)
+	assert.True(t, stale.Info.Cached)
+	assert.Greater(t, stale.Info.Age, time.Duration(0))
+	assert.Equal(t, fresh.Station, stale.Station)
+
+	// There is no previous response for another station.
+	_, err = client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NP"})
+	assert.Error(t, err)
+}
+
+func TestStaleFallbackWithCache(t *testing.T) {
+	server := newFailingServer(t)
+	defer server.Close()
+
+	cache := raildata.NewMemoryCache(10)
+	client, err := raildata.NewClient(
+		withServerUrl(t, server),
+		raildata.WithToken(testToken),
+		raildata.WithCache(cache),
+		raildata.WithCacheTTL("getTrainSchedule", time.Millisecond),
+		raildata.WithStaleFallback(time.Minute))
+	require.NoError(t, err)
+
+	_, err = client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+	require.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+
+	stale, err := client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+	require.NoError(t, err)
+	assert.True(t, stale.Info.Stale)
+}
+
+func TestStaleFallbackRespectsMaxStaleness(t *testing.T) {
+	server := newFailingServer(t)
+	defer server.Close()
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithStaleFallback(time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+	require.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+
+	_, err = client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+	var herr *errors.HttpStatusError
+	assert.ErrorAs(t, err, &herr)
+}
+
+func TestStaleFallbackOnlyForTransientErrors(t *testing.T) {
+	server := newServerFailingWith(t, expectRequest(t, "getTrainSchedule").sendError("some error message"))
+	defer server.Close()
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithStaleFallback(time.Minute))
+	require.NoError(t, err)
+
+	_, err = client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+	require.NoError(t, err)
+
+	_, err = client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+	var rderr *errors.RailDataError
+	assert.ErrorAs(t, err, &rderr)
+}
+
+func TestStaleFallbackSkipsIsValidToken(t *testing.T) {
+	var count atomic.Int32
+	good := expectRequest(t, "isValidToken").sendJson(`{"validToken":true,"userID":"the-user-id"}`)
+	bad := expectRequest(t, "isValidToken").sendResponse(503, "")
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if count.Add(1) == 1 {
+			good.ServeHTTP(rw, req)
+		} else {
+			bad.ServeHTTP(rw, req)
+		}
+	}))
+	defer server.Close()
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithStaleFallback(time.Minute))
+	require.NoError(t, err)
+
+	_, err = client.RateLimitedMethods().IsValidToken(context.Background())
+	require.NoError(t, err)
+
+	_, err = client.RateLimitedMethods().IsValidToken(context.Background())
+	var herr *errors.HttpStatusError
+	assert.ErrorAs(t, err, &herr)
+}
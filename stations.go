@@ -0,0 +1,257 @@
+package raildata
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+)
+
+// StationDetails contains geographic and service information about a station.
+type StationDetails struct {
+	// Code contains the station's 2-letter code.
+	Code StationCode
+	// Location contains the station's approximate GPS location.
+	Location Location
+	// Municipality contains the name of the municipality where the station is.
+	Municipality string
+	// County contains the name of the county where the station is, without the word "County".
+	County string
+	// State contains the 2-letter postal abbreviation of the state where the station is.
+	State string
+	// Accessible is true for stations that are known to be accessible to people with disabilities,
+	// and nil if the station's accessibility is not known. Check with NJ Transit for up-to-date information.
+	Accessible *bool
+	// Lines contains the codes of the lines that serve this station.
+	Lines []LineCode
+}
+
+// StationDetailsList contains the details for every station in [Stations].
+var StationDetailsList = []StationDetails{
+	{Code: "AM", Location: Location{Latitude: 40.4199, Longitude: -74.2221}, Municipality: "Aberdeen", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "AB", Location: Location{Latitude: 39.4241, Longitude: -74.5021}, Municipality: "Absecon", County: "Atlantic", State: "NJ", Lines: []LineCode{"AC"}},
+	{Code: "AZ", Location: Location{Latitude: 41.0302, Longitude: -74.1310}, Municipality: "Allendale", County: "Bergen", State: "NJ", Lines: []LineCode{"ML", "BC"}},
+	{Code: "AH", Location: Location{Latitude: 40.2372, Longitude: -74.0062}, Municipality: "Allenhurst", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "AS", Location: Location{Latitude: 40.8942, Longitude: -74.0437}, Municipality: "Hackensack", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+	{Code: "AN", Location: Location{Latitude: 40.6410, Longitude: -74.8814}, Municipality: "Clinton Township", County: "Hunterdon", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "AP", Location: Location{Latitude: 40.2157, Longitude: -74.0148}, Municipality: "Asbury Park", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "AO", Location: Location{Latitude: 39.7831, Longitude: -74.9075}, Municipality: "Waterford Township", County: "Camden", State: "NJ", Lines: []LineCode{"AC"}},
+	{Code: "AC", Location: Location{Latitude: 39.3626, Longitude: -74.4418}, Municipality: "Atlantic City", County: "Atlantic", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"AC"}},
+	{Code: "AV", Location: Location{Latitude: 40.5780, Longitude: -74.2774}, Municipality: "Woodbridge Township", County: "Middlesex", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "BA", Location: Location{Latitude: 39.1924, Longitude: -76.6940}, Municipality: "Linthicum", County: "Anne Arundel", State: "MD", Accessible: ptr(true), Lines: []LineCode{"AM"}},
+	{Code: "BL", Location: Location{Latitude: 39.3073, Longitude: -76.6156}, Municipality: "Baltimore", County: "Baltimore City", State: "MD", Accessible: ptr(true), Lines: []LineCode{"AM"}},
+	{Code: "BI", Location: Location{Latitude: 40.7111, Longitude: -74.5550}, Municipality: "Bernards Township", County: "Somerset", State: "NJ", Lines: []LineCode{"GS"}},
+	{Code: "BH", Location: Location{Latitude: 40.0772, Longitude: -74.0461}, Municipality: "Bay Head", County: "Ocean", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "MC", Location: Location{Latitude: 40.8083, Longitude: -74.2085}, Municipality: "Montclair", County: "Essex", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "BS", Location: Location{Latitude: 40.1810, Longitude: -74.0273}, Municipality: "Belmar", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "BY", Location: Location{Latitude: 40.6824, Longitude: -74.4426}, Municipality: "Berkeley Heights", County: "Union", State: "NJ", Lines: []LineCode{"GS"}},
+	{Code: "BV", Location: Location{Latitude: 40.7170, Longitude: -74.5712}, Municipality: "Bernardsville", County: "Somerset", State: "NJ", Lines: []LineCode{"GS"}},
+	{Code: "BM", Location: Location{Latitude: 40.7925, Longitude: -74.2002}, Municipality: "Bloomfield", County: "Essex", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "BN", Location: Location{Latitude: 40.9033, Longitude: -74.4076}, Municipality: "Boonton", County: "Morris", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "BK", Location: Location{Latitude: 40.5607, Longitude: -74.5301}, Municipality: "Bound Brook", County: "Somerset", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "BB", Location: Location{Latitude: 40.2030, Longitude: -74.0188}, Municipality: "Bradley Beach", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "BU", Location: Location{Latitude: 40.7657, Longitude: -74.2191}, Municipality: "East Orange", County: "Essex", State: "NJ", Lines: []LineCode{"ME", "GS"}},
+	{Code: "BW", Location: Location{Latitude: 40.5596, Longitude: -74.5514}, Municipality: "Bridgewater Township", County: "Somerset", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "BF", Location: Location{Latitude: 40.9222, Longitude: -74.1151}, Municipality: "Fair Lawn", County: "Bergen", State: "NJ", Lines: []LineCode{"BC"}},
+	{Code: "CB", Location: Location{Latitude: 41.4507, Longitude: -74.2663}, Municipality: "Hamptonburgh", County: "Orange", State: "NY", Lines: []LineCode{"ML"}},
+	{Code: "CM", Location: Location{Latitude: 40.7402, Longitude: -74.3848}, Municipality: "Chatham", County: "Morris", State: "NJ", Lines: []LineCode{"ME"}},
+	{Code: "CY", Location: Location{Latitude: 39.9282, Longitude: -75.0414}, Municipality: "Cherry Hill", County: "Camden", State: "NJ", Lines: []LineCode{"AC"}},
+	{Code: "IF", Location: Location{Latitude: 40.8678, Longitude: -74.1533}, Municipality: "Clifton", County: "Passaic", State: "NJ", Lines: []LineCode{"ML"}},
+	{Code: "CN", Location: Location{Latitude: 40.7789, Longitude: -74.4434}, Municipality: "Morris Township", County: "Morris", State: "NJ", Lines: []LineCode{"ME"}},
+	{Code: "XC", Location: Location{Latitude: 40.6558, Longitude: -74.3037}, Municipality: "Cranford", County: "Union", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "DL", Location: Location{Latitude: 40.8319, Longitude: -74.1314}, Municipality: "Clifton", County: "Passaic", State: "NJ", Lines: []LineCode{"ML"}},
+	{Code: "DV", Location: Location{Latitude: 40.8836, Longitude: -74.4818}, Municipality: "Denville", County: "Morris", State: "NJ", Lines: []LineCode{"ME", "MC"}},
+	{Code: "DO", Location: Location{Latitude: 40.8875, Longitude: -74.5559}, Municipality: "Dover", County: "Morris", State: "NJ", Lines: []LineCode{"ME", "MC"}},
+	{Code: "DN", Location: Location{Latitude: 40.5898, Longitude: -74.4633}, Municipality: "Dunellen", County: "Middlesex", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "EO", Location: Location{Latitude: 40.7609, Longitude: -74.2109}, Municipality: "East Orange", County: "Essex", State: "NJ", Lines: []LineCode{"ME", "GS"}},
+	{Code: "ED", Location: Location{Latitude: 40.5193, Longitude: -74.4107}, Municipality: "Edison", County: "Middlesex", State: "NJ", Lines: []LineCode{"NE"}},
+	{Code: "EH", Location: Location{Latitude: 39.5270, Longitude: -74.6483}, Municipality: "Egg Harbor City", County: "Atlantic", State: "NJ", Lines: []LineCode{"AC"}},
+	{Code: "EL", Location: Location{Latitude: 40.2651, Longitude: -73.9972}, Municipality: "Long Branch", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "EZ", Location: Location{Latitude: 40.6672, Longitude: -74.2155}, Municipality: "Elizabeth", County: "Union", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"NE", "NC"}},
+	{Code: "EN", Location: Location{Latitude: 40.9757, Longitude: -74.0273}, Municipality: "Emerson", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+	{Code: "EX", Location: Location{Latitude: 40.8791, Longitude: -74.0516}, Municipality: "Hackensack", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+	{Code: "FW", Location: Location{Latitude: 40.6409, Longitude: -74.3853}, Municipality: "Fanwood", County: "Union", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "FH", Location: Location{Latitude: 40.6856, Longitude: -74.6337}, Municipality: "Far Hills", County: "Somerset", State: "NJ", Lines: []LineCode{"GS"}},
+	{Code: "FE", Location: Location{Latitude: 40.5634, Longitude: -74.5744}, Municipality: "Bridgewater Township", County: "Somerset", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "GD", Location: Location{Latitude: 40.8669, Longitude: -74.1051}, Municipality: "Garfield", County: "Bergen", State: "NJ", Lines: []LineCode{"BC"}},
+	{Code: "GW", Location: Location{Latitude: 40.6526, Longitude: -74.3247}, Municipality: "Garwood", County: "Union", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "GI", Location: Location{Latitude: 40.6784, Longitude: -74.4683}, Municipality: "Long Hill Township", County: "Morris", State: "NJ", Lines: []LineCode{"GS"}},
+	{Code: "GL", Location: Location{Latitude: 40.7202, Longitude: -74.6661}, Municipality: "Peapack-Gladstone", County: "Somerset", State: "NJ", Lines: []LineCode{"GS"}},
+	{Code: "GG", Location: Location{Latitude: 40.8006, Longitude: -74.2043}, Municipality: "Glen Ridge", County: "Essex", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "GK", Location: Location{Latitude: 40.9617, Longitude: -74.1292}, Municipality: "Glen Rock", County: "Bergen", State: "NJ", Lines: []LineCode{"BC"}},
+	{Code: "RS", Location: Location{Latitude: 40.9628, Longitude: -74.1331}, Municipality: "Glen Rock", County: "Bergen", State: "NJ", Lines: []LineCode{"ML"}},
+	{Code: "GA", Location: Location{Latitude: 40.8955, Longitude: -74.2059}, Municipality: "Little Falls", County: "Passaic", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "HQ", Location: Location{Latitude: 40.8519, Longitude: -74.8354}, Municipality: "Hackettstown", County: "Warren", State: "NJ", Lines: []LineCode{"ME", "MC"}},
+	{Code: "HL", Location: Location{Latitude: 40.2553, Longitude: -74.7041}, Municipality: "Hamilton Township", County: "Mercer", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"NE"}},
+	{Code: "HN", Location: Location{Latitude: 39.6318, Longitude: -74.7993}, Municipality: "Hammonton", County: "Atlantic", State: "NJ", Lines: []LineCode{"AC"}},
+	{Code: "RM", Location: Location{Latitude: 41.3059, Longitude: -74.1531}, Municipality: "Woodbury", County: "Orange", State: "NY", Lines: []LineCode{"ML"}},
+	{Code: "HW", Location: Location{Latitude: 40.9424, Longitude: -74.1527}, Municipality: "Hawthorne", County: "Passaic", State: "NJ", Lines: []LineCode{"ML"}},
+	{Code: "HZ", Location: Location{Latitude: 40.4153, Longitude: -74.1907}, Municipality: "Hazlet", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "HG", Location: Location{Latitude: 40.6668, Longitude: -74.8958}, Municipality: "High Bridge", County: "Hunterdon", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "HI", Location: Location{Latitude: 40.7669, Longitude: -74.2437}, Municipality: "Orange", County: "Essex", State: "NJ", Lines: []LineCode{"ME", "GS"}},
+	{Code: "HD", Location: Location{Latitude: 40.9947, Longitude: -74.0414}, Municipality: "Hillsdale", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+	{Code: "HB", Location: Location{Latitude: 40.7352, Longitude: -74.0275}, Municipality: "Hoboken", County: "Hudson", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"ME", "GS", "MC", "ML", "BC", "PV", "NC", "SL"}},
+	{Code: "UF", Location: Location{Latitude: 40.9975, Longitude: -74.1133}, Municipality: "Ho-Ho-Kus", County: "Bergen", State: "NJ", Lines: []LineCode{"ML", "BC"}},
+	{Code: "JA", Location: Location{Latitude: 40.4768, Longitude: -74.4673}, Municipality: "New Brunswick", County: "Middlesex", State: "NJ", Lines: []LineCode{"NE"}},
+	{Code: "KG", Location: Location{Latitude: 40.8098, Longitude: -74.1170}, Municipality: "Lyndhurst", County: "Bergen", State: "NJ", Lines: []LineCode{"ML"}},
+	{Code: "HP", Location: Location{Latitude: 40.9040, Longitude: -74.6655}, Municipality: "Roxbury Township", County: "Morris", State: "NJ", Lines: []LineCode{"ME", "MC"}},
+	{Code: "ON", Location: Location{Latitude: 40.6365, Longitude: -74.8360}, Municipality: "Lebanon", County: "Hunterdon", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "LP", Location: Location{Latitude: 40.9244, Longitude: -74.3016}, Municipality: "Lincoln Park", County: "Morris", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "LI", Location: Location{Latitude: 40.6295, Longitude: -74.2516}, Municipality: "Linden", County: "Union", State: "NJ", Lines: []LineCode{"NE", "NC"}},
+	{Code: "LW", Location: Location{Latitude: 39.8337, Longitude: -74.9993}, Municipality: "Lindenwold", County: "Camden", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"AC"}},
+	{Code: "FA", Location: Location{Latitude: 40.8804, Longitude: -74.2353}, Municipality: "Little Falls", County: "Passaic", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "LS", Location: Location{Latitude: 40.3266, Longitude: -74.0414}, Municipality: "Little Silver", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "LB", Location: Location{Latitude: 40.2970, Longitude: -73.9885}, Municipality: "Long Branch", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "LN", Location: Location{Latitude: 40.8164, Longitude: -74.1223}, Municipality: "Lyndhurst", County: "Bergen", State: "NJ", Lines: []LineCode{"ML"}},
+	{Code: "LY", Location: Location{Latitude: 40.6847, Longitude: -74.5496}, Municipality: "Bernards Township", County: "Somerset", State: "NJ", Lines: []LineCode{"GS"}},
+	{Code: "MA", Location: Location{Latitude: 40.7570, Longitude: -74.4153}, Municipality: "Madison", County: "Morris", State: "NJ", Lines: []LineCode{"ME"}},
+	{Code: "MZ", Location: Location{Latitude: 41.0943, Longitude: -74.1461}, Municipality: "Mahwah", County: "Bergen", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"ML", "BC"}},
+	{Code: "SQ", Location: Location{Latitude: 40.1208, Longitude: -74.0475}, Municipality: "Manasquan", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "MW", Location: Location{Latitude: 40.7311, Longitude: -74.2753}, Municipality: "Maplewood", County: "Essex", State: "NJ", Lines: []LineCode{"ME", "GS"}},
+	{Code: "XU", Location: Location{Latitude: 40.8131, Longitude: -74.0727}, Municipality: "East Rutherford", County: "Bergen", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"SL"}},
+	{Code: "MP", Location: Location{Latitude: 40.5683, Longitude: -74.3297}, Municipality: "Woodbridge Township", County: "Middlesex", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"NE", "AM"}},
+	{Code: "MU", Location: Location{Latitude: 40.5402, Longitude: -74.3605}, Municipality: "Metuchen", County: "Middlesex", State: "NJ", Lines: []LineCode{"NE"}},
+	{Code: "MI", Location: Location{Latitude: 40.3896, Longitude: -74.1162}, Municipality: "Middletown Township", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "MD", Location: Location{Latitude: 41.4573, Longitude: -74.3710}, Municipality: "Wallkill", County: "Orange", State: "NY", Lines: []LineCode{"ML"}},
+	{Code: "MB", Location: Location{Latitude: 40.7258, Longitude: -74.3037}, Municipality: "Millburn", County: "Essex", State: "NJ", Lines: []LineCode{"ME", "GS"}},
+	{Code: "GO", Location: Location{Latitude: 40.6734, Longitude: -74.5236}, Municipality: "Long Hill Township", County: "Morris", State: "NJ", Lines: []LineCode{"GS"}},
+	{Code: "MK", Location: Location{Latitude: 40.3133, Longitude: -74.0158}, Municipality: "Oceanport", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "HS", Location: Location{Latitude: 40.8573, Longitude: -74.2024}, Municipality: "Montclair", County: "Essex", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "UV", Location: Location{Latitude: 40.8697, Longitude: -74.1974}, Municipality: "Little Falls", County: "Passaic", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"MC"}},
+	{Code: "ZM", Location: Location{Latitude: 41.0407, Longitude: -74.0292}, Municipality: "Montvale", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+	{Code: "MX", Location: Location{Latitude: 40.8284, Longitude: -74.4784}, Municipality: "Morris Plains", County: "Morris", State: "NJ", Lines: []LineCode{"ME"}},
+	{Code: "MR", Location: Location{Latitude: 40.7975, Longitude: -74.4745}, Municipality: "Morristown", County: "Morris", State: "NJ", Lines: []LineCode{"ME"}},
+	{Code: "HV", Location: Location{Latitude: 40.8970, Longitude: -74.6325}, Municipality: "Mount Arlington", County: "Morris", State: "NJ", Lines: []LineCode{"ME", "MC"}},
+	{Code: "OL", Location: Location{Latitude: 40.9075, Longitude: -74.7307}, Municipality: "Mount Olive Township", County: "Morris", State: "NJ", Lines: []LineCode{"ME", "MC"}},
+	{Code: "TB", Location: Location{Latitude: 40.8760, Longitude: -74.4818}, Municipality: "Parsippany-Troy Hills", County: "Morris", State: "NJ", Lines: []LineCode{"ME"}},
+	{Code: "MS", Location: Location{Latitude: 40.8486, Longitude: -74.2053}, Municipality: "Montclair", County: "Essex", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "ML", Location: Location{Latitude: 40.8860, Longitude: -74.4336}, Municipality: "Mountain Lakes", County: "Morris", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "MT", Location: Location{Latitude: 40.7553, Longitude: -74.2532}, Municipality: "South Orange", County: "Essex", State: "NJ", Lines: []LineCode{"ME", "GS"}},
+	{Code: "MV", Location: Location{Latitude: 40.9137, Longitude: -74.2678}, Municipality: "Wayne", County: "Passaic", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "MH", Location: Location{Latitude: 40.6952, Longitude: -74.4033}, Municipality: "New Providence", County: "Union", State: "NJ", Lines: []LineCode{"GS"}},
+	{Code: "NN", Location: Location{Latitude: 41.0889, Longitude: -74.0136}, Municipality: "Clarkstown", County: "Rockland", State: "NY", Lines: []LineCode{"PV"}},
+	{Code: "NT", Location: Location{Latitude: 40.8978, Longitude: -74.7075}, Municipality: "Netcong", County: "Morris", State: "NJ", Lines: []LineCode{"ME", "MC"}},
+	{Code: "NE", Location: Location{Latitude: 40.6294, Longitude: -74.4035}, Municipality: "Plainfield", County: "Union", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "NH", Location: Location{Latitude: 40.9107, Longitude: -74.0350}, Municipality: "River Edge", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+	{Code: "NB", Location: Location{Latitude: 40.4966, Longitude: -74.4456}, Municipality: "New Brunswick", County: "Middlesex", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"NE", "AM"}},
+	{Code: "NC", Location: Location{Latitude: 38.9480, Longitude: -76.8720}, Municipality: "New Carrollton", County: "Prince George's", State: "MD", Accessible: ptr(true), Lines: []LineCode{"AM"}},
+	{Code: "NV", Location: Location{Latitude: 40.7120, Longitude: -74.3864}, Municipality: "New Providence", County: "Union", State: "NJ", Lines: []LineCode{"GS"}},
+	{Code: "NY", Location: Location{Latitude: 40.7506, Longitude: -73.9935}, Municipality: "New York", County: "New York", State: "NY", Accessible: ptr(true), Lines: []LineCode{"NE", "NC", "ME", "GS", "MC", "AM"}},
+	{Code: "NA", Location: Location{Latitude: 40.7045, Longitude: -74.1903}, Municipality: "Newark", County: "Essex", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"NE", "NC"}},
+	{Code: "ND", Location: Location{Latitude: 40.7475, Longitude: -74.1717}, Municipality: "Newark", County: "Essex", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"ME", "GS", "MC"}},
+	{Code: "NP", Location: Location{Latitude: 40.7345, Longitude: -74.1644}, Municipality: "Newark", County: "Essex", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"NE", "NC", "RV", "AM"}},
+	{Code: "OR", Location: Location{Latitude: 40.5924, Longitude: -74.6838}, Municipality: "Branchburg", County: "Somerset", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "NZ", Location: Location{Latitude: 40.6804, Longitude: -74.2066}, Municipality: "Elizabeth", County: "Union", State: "NJ", Lines: []LineCode{"NE", "NC"}},
+	{Code: "NF", Location: Location{Latitude: 39.9975, Longitude: -75.1554}, Municipality: "Philadelphia", County: "Philadelphia", State: "PA", Lines: []LineCode{"AM", "SP"}},
+	{Code: "OD", Location: Location{Latitude: 40.9530, Longitude: -74.0304}, Municipality: "Oradell", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+	{Code: "OG", Location: Location{Latitude: 40.7721, Longitude: -74.2331}, Municipality: "Orange", County: "Essex", State: "NJ", Lines: []LineCode{"ME", "GS"}},
+	{Code: "OS", Location: Location{Latitude: 41.4718, Longitude: -74.5288}, Municipality: "Mount Hope", County: "Orange", State: "NY", Lines: []LineCode{"ML"}},
+	{Code: "PV", Location: Location{Latitude: 41.0323, Longitude: -74.0362}, Municipality: "Park Ridge", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+	{Code: "PS", Location: Location{Latitude: 40.8492, Longitude: -74.1339}, Municipality: "Passaic", County: "Passaic", State: "NJ", Lines: []LineCode{"ML"}},
+	{Code: "RN", Location: Location{Latitude: 40.9142, Longitude: -74.1677}, Municipality: "Paterson", County: "Passaic", State: "NJ", Lines: []LineCode{"ML"}},
+	{Code: "PC", Location: Location{Latitude: 40.7083, Longitude: -74.6588}, Municipality: "Peapack-Gladstone", County: "Somerset", State: "NJ", Lines: []LineCode{"GS"}},
+	{Code: "PQ", Location: Location{Latitude: 41.0589, Longitude: -74.0222}, Municipality: "Orangetown", County: "Rockland", State: "NY", Lines: []LineCode{"PV"}},
+	{Code: "PN", Location: Location{Latitude: 39.9778, Longitude: -75.0614}, Municipality: "Pennsauken Township", County: "Camden", State: "NJ", Lines: []LineCode{"AC"}},
+	{Code: "PE", Location: Location{Latitude: 40.5095, Longitude: -74.2737}, Municipality: "Perth Amboy", County: "Middlesex", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "PH", Location: Location{Latitude: 39.9566, Longitude: -75.1820}, Municipality: "Philadelphia", County: "Philadelphia", State: "PA", Accessible: ptr(true), Lines: []LineCode{"AC", "AM", "SP"}},
+	{Code: "PF", Location: Location{Latitude: 40.6181, Longitude: -74.4203}, Municipality: "Plainfield", County: "Union", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "PL", Location: Location{Latitude: 40.8839, Longitude: -74.1026}, Municipality: "Garfield", County: "Bergen", State: "NJ", Lines: []LineCode{"BC"}},
+	{Code: "PP", Location: Location{Latitude: 40.0924, Longitude: -74.0482}, Municipality: "Point Pleasant Beach", County: "Ocean", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "PO", Location: Location{Latitude: 41.3747, Longitude: -74.6946}, Municipality: "Port Jervis", County: "Orange", State: "NY", Lines: []LineCode{"ML"}},
+	{Code: "PR", Location: Location{Latitude: 40.3431, Longitude: -74.6599}, Municipality: "Princeton", County: "Mercer", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"PR"}},
+	{Code: "PJ", Location: Location{Latitude: 40.3166, Longitude: -74.6236}, Municipality: "West Windsor", County: "Mercer", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"NE", "PR", "AM"}},
+	{Code: "FZ", Location: Location{Latitude: 40.9396, Longitude: -74.1213}, Municipality: "Fair Lawn", County: "Bergen", State: "NJ", Lines: []LineCode{"BC"}},
+	{Code: "RH", Location: Location{Latitude: 40.6062, Longitude: -74.2765}, Municipality: "Rahway", County: "Union", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"NE", "NC"}},
+	{Code: "RY", Location: Location{Latitude: 41.0574, Longitude: -74.1421}, Municipality: "Ramsey", County: "Bergen", State: "NJ", Lines: []LineCode{"ML", "BC"}},
+	{Code: "17", Location: Location{Latitude: 41.0718, Longitude: -74.1455}, Municipality: "Ramsey", County: "Bergen", State: "NJ", Lines: []LineCode{"ML", "BC"}},
+	{Code: "RA", Location: Location{Latitude: 40.5711, Longitude: -74.6343}, Municipality: "Raritan", County: "Somerset", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "RB", Location: Location{Latitude: 40.3485, Longitude: -74.0743}, Municipality: "Red Bank", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "RW", Location: Location{Latitude: 40.9807, Longitude: -74.1203}, Municipality: "Ridgewood", County: "Bergen", State: "NJ", Lines: []LineCode{"ML", "BC"}},
+	{Code: "RG", Location: Location{Latitude: 40.9353, Longitude: -74.0293}, Municipality: "River Edge", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+	{Code: "RL", Location: Location{Latitude: 40.6670, Longitude: -74.2665}, Municipality: "Roselle Park", County: "Union", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "RF", Location: Location{Latitude: 40.8281, Longitude: -74.1007}, Municipality: "Rutherford", County: "Bergen", State: "NJ", Lines: []LineCode{"BC"}},
+	{Code: "CW", Location: Location{Latitude: 41.4370, Longitude: -74.1015}, Municipality: "New Windsor", County: "Orange", State: "NY", Lines: []LineCode{"ML"}},
+	{Code: "SC", Location: Location{Latitude: 40.7612, Longitude: -74.0758}, Municipality: "Secaucus", County: "Hudson", State: "NJ", Accessible: ptr(true)},
+	{Code: "TS", Location: Location{Latitude: 40.7612, Longitude: -74.0758}, Municipality: "Secaucus", County: "Hudson", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"ML", "BC", "PV", "SL"}},
+	{Code: "SE", Location: Location{Latitude: 40.7612, Longitude: -74.0758}, Municipality: "Secaucus", County: "Hudson", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"NE", "NC", "ME", "GS", "MC"}},
+	{Code: "RT", Location: Location{Latitude: 40.7253, Longitude: -74.3238}, Municipality: "Millburn", County: "Essex", State: "NJ", Lines: []LineCode{"ME", "GS"}},
+	{Code: "XG", Location: Location{Latitude: 41.1569, Longitude: -74.1913}, Municipality: "Ramapo", County: "Rockland", State: "NY", Lines: []LineCode{"ML"}},
+	{Code: "SM", Location: Location{Latitude: 40.5661, Longitude: -74.6139}, Municipality: "Somerville", County: "Somerset", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"RV"}},
+	{Code: "CH", Location: Location{Latitude: 40.4846, Longitude: -74.2806}, Municipality: "South Amboy", County: "Middlesex", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "SO", Location: Location{Latitude: 40.7460, Longitude: -74.2604}, Municipality: "South Orange", County: "Essex", State: "NJ", Lines: []LineCode{"ME", "GS"}},
+	{Code: "LA", Location: Location{Latitude: 40.1509, Longitude: -74.0357}, Municipality: "Spring Lake", County: "Monmouth", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "SV", Location: Location{Latitude: 41.1112, Longitude: -74.0437}, Municipality: "Spring Valley", County: "Rockland", State: "NY", Lines: []LineCode{"PV"}},
+	{Code: "SG", Location: Location{Latitude: 40.6747, Longitude: -74.4934}, Municipality: "Long Hill Township", County: "Morris", State: "NJ", Lines: []LineCode{"GS"}},
+	{Code: "SF", Location: Location{Latitude: 41.1144, Longitude: -74.1538}, Municipality: "Suffern", County: "Rockland", State: "NY", Lines: []LineCode{"ML", "BC"}},
+	{Code: "ST", Location: Location{Latitude: 40.7166, Longitude: -74.3577}, Municipality: "Summit", County: "Union", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"ME", "GS"}},
+	{Code: "TE", Location: Location{Latitude: 40.8645, Longitude: -74.0625}, Municipality: "Teterboro", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+	{Code: "TO", Location: Location{Latitude: 40.9232, Longitude: -74.3434}, Municipality: "Montville", County: "Morris", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "TR", Location: Location{Latitude: 40.2178, Longitude: -74.7543}, Municipality: "Trenton", County: "Mercer", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"NE", "AM", "SP"}},
+	{Code: "TC", Location: Location{Latitude: 41.1944, Longitude: -74.1844}, Municipality: "Tuxedo", County: "Orange", State: "NY", Lines: []LineCode{"ML"}},
+	{Code: "US", Location: Location{Latitude: 40.6835, Longitude: -74.2381}, Municipality: "Union Township", County: "Union", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "UM", Location: Location{Latitude: 40.8424, Longitude: -74.2094}, Municipality: "Montclair", County: "Essex", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "WK", Location: Location{Latitude: 41.0125, Longitude: -74.1234}, Municipality: "Waldwick", County: "Bergen", State: "NJ", Lines: []LineCode{"ML", "BC"}},
+	{Code: "WA", Location: Location{Latitude: 40.8174, Longitude: -74.2094}, Municipality: "Montclair", County: "Essex", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "WS", Location: Location{Latitude: 38.8973, Longitude: -77.0063}, Municipality: "Washington", County: "District of Columbia", State: "DC", Accessible: ptr(true), Lines: []LineCode{"AM"}},
+	{Code: "WG", Location: Location{Latitude: 40.8294, Longitude: -74.2069}, Municipality: "Montclair", County: "Essex", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "WT", Location: Location{Latitude: 40.7829, Longitude: -74.1985}, Municipality: "Bloomfield", County: "Essex", State: "NJ", Lines: []LineCode{"MC"}},
+	{Code: "23", Location: Location{Latitude: 40.9000, Longitude: -74.2567}, Municipality: "Wayne", County: "Passaic", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"MC"}},
+	{Code: "WM", Location: Location{Latitude: 40.8543, Longitude: -74.0967}, Municipality: "Wood-Ridge", County: "Bergen", State: "NJ", Accessible: ptr(true), Lines: []LineCode{"BC"}},
+	{Code: "WF", Location: Location{Latitude: 40.6497, Longitude: -74.3474}, Municipality: "Westfield", County: "Union", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "WW", Location: Location{Latitude: 40.9910, Longitude: -74.0329}, Municipality: "Westwood", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+	{Code: "WH", Location: Location{Latitude: 40.6155, Longitude: -74.7705}, Municipality: "Readington Township", County: "Hunterdon", State: "NJ", Lines: []LineCode{"RV"}},
+	{Code: "WI", Location: Location{Latitude: 39.7369, Longitude: -75.5511}, Municipality: "Wilmington", County: "New Castle", State: "DE", Accessible: ptr(true), Lines: []LineCode{"AM", "SP"}},
+	{Code: "WR", Location: Location{Latitude: 40.8434, Longitude: -74.0787}, Municipality: "Wood-Ridge", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+	{Code: "WB", Location: Location{Latitude: 40.5560, Longitude: -74.2779}, Municipality: "Woodbridge Township", County: "Middlesex", State: "NJ", Lines: []LineCode{"NC"}},
+	{Code: "WL", Location: Location{Latitude: 41.0212, Longitude: -74.0406}, Municipality: "Woodcliff Lake", County: "Bergen", State: "NJ", Lines: []LineCode{"PV"}},
+}
+
+var stationDetailsByCode = makeMap(StationDetailsList, func(s *StationDetails) StationCode { return s.Code })
+
+// GetStationDetails returns the details for the station with the given code, or false if they are not known.
+func GetStationDetails(code StationCode) (*StationDetails, bool) {
+	details, found := stationDetailsByCode[strings.ToLower(string(code))]
+	return details, found
+}
+
+// NearbyStation contains a station and its distance to a location.
+type NearbyStation struct {
+	// Station contains the station.
+	Station Station
+	// Details contains the station's details.
+	Details *StationDetails
+	// Distance contains the distance from the location to the station, in meters.
+	Distance float64
+}
+
+// NearestStations returns the n stations closest to the given location, sorted by distance.
+func NearestStations(location Location, n int) []NearbyStation {
+	out := make([]NearbyStation, 0, len(StationDetailsList))
+	for i := range StationDetailsList {
+		details := &StationDetailsList[i]
+		station, found := FindStation().WithCode(details.Code).Search()
+		if !found {
+			continue
+		}
+		out = append(out, NearbyStation{Station: *station, Details: details, Distance: location.DistanceTo(details.Location)})
+	}
+	slices.SortStableFunc(out, func(a, b NearbyStation) int { return cmp.Compare(a.Distance, b.Distance) })
+	return out[:min(max(n, 0), len(out))]
+}
+
+const earthRadius = 6371000
+
+// DistanceTo returns the great-circle distance between two locations, in meters.
+func (l Location) DistanceTo(other Location) float64 {
+	lat1 := l.Latitude * math.Pi / 180
+	lat2 := other.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (other.Longitude - l.Longitude) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// ptr returns a pointer to a copy of the value, so table entries don't share it.
+func ptr[T any](value T) *T {
+	return &value
+}
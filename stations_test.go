@@ -0,0 +1,67 @@
+package raildata_test
+
+import (
+	"testing"
+
+	"github.com/jtarrio/raildata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEveryStationHasDetails(t *testing.T) {
+	for _, station := range raildata.Stations {
+		details, found := raildata.GetStationDetails(station.Code)
+		if !assert.True(t, found, "station %s", station.Code) {
+			continue
+		}
+		assert.NotZero(t, details.Location.Latitude, "station %s", station.Code)
+		assert.NotEmpty(t, details.Municipality, "station %s", station.Code)
+		assert.Len(t, details.State, 2, "station %s", station.Code)
+		for _, line := range details.Lines {
+			_, found := raildata.FindLine().WithCode(line).Search()
+			assert.True(t, found, "station %s, line %s", station.Code, line)
+		}
+	}
+	assert.Len(t, raildata.StationDetailsList, len(raildata.Stations))
+}
+
+func TestGetStationDetails(t *testing.T) {
+	details, found := raildata.GetStationDetails("PJ")
+	require.True(t, found)
+	assert.Equal(t, "West Windsor", details.Municipality)
+	assert.Equal(t, "Mercer", details.County)
+	assert.Equal(t, "NJ", details.State)
+	assert.Equal(t, ptr(true), details.Accessible)
+	assert.ElementsMatch(t, []raildata.LineCode{"NE", "PR", "AM"}, details.Lines)
+
+	_, found = raildata.GetStationDetails("XX")
+	assert.False(t, found)
+}
+
+func TestStationDetailsDontShareValues(t *testing.T) {
+	pj, _ := raildata.GetStationDetails("PJ")
+	nb, _ := raildata.GetStationDetails("NB")
+	require.NotNil(t, pj.Accessible)
+	require.NotNil(t, nb.Accessible)
+	assert.NotSame(t, pj.Accessible, nb.Accessible)
+}
+
+func TestNearestStations(t *testing.T) {
+	// Nassau Hall, Princeton.
+	nearest := raildata.NearestStations(raildata.Location{Latitude: 40.3487, Longitude: -74.6593}, 2)
+	require.Len(t, nearest, 2)
+	assert.Equal(t, raildata.StationCode("PR"), nearest[0].Station.Code)
+	assert.Equal(t, raildata.StationCode("PJ"), nearest[1].Station.Code)
+	assert.InDelta(t, 620, nearest[0].Distance, 100)
+	assert.Less(t, nearest[0].Distance, nearest[1].Distance)
+
+	assert.Empty(t, raildata.NearestStations(raildata.Location{}, 0))
+	assert.Len(t, raildata.NearestStations(raildata.Location{}, 1000), len(raildata.Stations))
+}
+
+func TestLocationDistance(t *testing.T) {
+	ny, _ := raildata.GetStationDetails("NY")
+	np, _ := raildata.GetStationDetails("NP")
+	assert.InDelta(t, 14800, ny.Location.DistanceTo(np.Location), 500)
+	assert.Zero(t, ny.Location.DistanceTo(ny.Location))
+}
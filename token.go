@@ -0,0 +1,180 @@
+package raildata
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	rderrors "github.com/jtarrio/raildata/errors"
+)
+
+// TokenInfo contains what the client knows about the token it is currently using.
+type TokenInfo struct {
+	// IssuedAt contains the date/time this client created the token, if it did.
+	IssuedAt *time.Time
+	// LastValidated contains the date/time of the last successful request made with the token, if any.
+	LastValidated *time.Time
+	// Lifetime contains the estimated token lifetime, if known. It is learned by observing how long
+	// tokens last before the server rejects them, or it can be provided with [WithTokenLifetime].
+	Lifetime *time.Duration
+	// EstimatedExpiry contains the date/time the token is expected to expire, if known.
+	EstimatedExpiry *time.Time
+}
+
+// WithTokenLifetime sets the initial estimate for how long a token lasts before it expires.
+// The client will update the estimate when it observes a token expiring.
+func WithTokenLifetime(lifetime time.Duration) Option {
+	return func(s *raildataClient) {
+		s.tokenLifetime = lifetime
+	}
+}
+
+// WithProactiveTokenRefresh makes the client create a new token shortly before the current one is expected to expire,
+// so requests don't need to wait for a token refresh.
+//
+// The client only refreshes a token when it knows when the token was created and how long tokens last,
+// and only when there are no requests in progress. The margin specifies how long before the expected
+// expiration the token is refreshed. The background refresh stops when the context is cancelled.
+//
+// If a refresh fails, the client waits before trying again, starting at a tenth of the margin and doubling
+// the wait after every failure, up to the margin. The background refresh stops if the credentials are rejected
+// or the daily limit for creating tokens is reached.
+//
+// Note that every refresh creates a new token, and you can only create a few tokens per day.
+func WithProactiveTokenRefresh(ctx context.Context, margin time.Duration) Option {
+	return func(s *raildataClient) {
+		s.proactiveRefreshCtx = ctx
+		s.proactiveRefreshMargin = margin
+	}
+}
+
+func (s *raildataClient) GetTokenInfo() TokenInfo {
+	s.tokenMutex.Lock()
+	defer s.tokenMutex.Unlock()
+	info := TokenInfo{}
+	if !s.tokenIssuedAt.IsZero() {
+		issuedAt := s.tokenIssuedAt
+		info.IssuedAt = &issuedAt
+	}
+	if !s.tokenLastValidated.IsZero() {
+		lastValidated := s.tokenLastValidated
+		info.LastValidated = &lastValidated
+	}
+	if s.tokenLifetime > 0 {
+		lifetime := s.tokenLifetime
+		info.Lifetime = &lifetime
+	}
+	if expiry, known := s.tokenExpiry(); known {
+		info.EstimatedExpiry = &expiry
+	}
+	return info
+}
+
+// tokenExpiry returns the estimated expiration time for the current token. Must be called with tokenMutex held.
+func (s *raildataClient) tokenExpiry() (time.Time, bool) {
+	if s.tokenIssuedAt.IsZero() || s.tokenLifetime <= 0 {
+		return time.Time{}, false
+	}
+	return s.tokenIssuedAt.Add(s.tokenLifetime), true
+}
+
+// tokenReplaced records that the token was replaced. Must be called with tokenMutex held.
+func (s *raildataClient) tokenReplaced(created bool, now time.Time) {
+	s.tokenIssuedAt = time.Time{}
+	if created {
+		s.tokenIssuedAt = now
+	}
+	s.tokenLastValidated = time.Time{}
+	select {
+	case s.tokenChanged <- struct{}{}:
+	default:
+	}
+}
+
+// tokenValidated records that a request made with the given token succeeded.
+func (s *raildataClient) tokenValidated(token string, now time.Time) {
+	s.tokenMutex.Lock()
+	defer s.tokenMutex.Unlock()
+	if s.token == token {
+		s.tokenLastValidated = now
+	}
+}
+
+// tokenRejected records that the server rejected the given token, and learns the token lifetime from it.
+func (s *raildataClient) tokenRejected(token string, now time.Time) {
+	s.tokenMutex.Lock()
+	defer s.tokenMutex.Unlock()
+	if s.token != token || s.tokenIssuedAt.IsZero() {
+		return
+	}
+	// The token expired some time between the last successful request and now;
+	// we use the lower bound so the proactive refresh happens early rather than late.
+	lastValid := s.tokenLastValidated
+	if lastValid.IsZero() {
+		lastValid = now
+	}
+	s.tokenLifetime = lastValid.Sub(s.tokenIssuedAt)
+	s.logger.Info("token expired", "lifetime", s.tokenLifetime)
+}
+
+func (s *raildataClient) runProactiveTokenRefresh(ctx context.Context, margin time.Duration) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	// backoff contains how long to wait after the last failed refresh, or 0 if the last refresh didn't fail.
+	var backoff time.Duration
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.tokenChanged:
+			backoff = 0
+		case now := <-timer.C:
+			if token, due := s.proactiveRefreshDue(now, margin); due {
+				s.logger.Info("refreshing token before it expires")
+				err := s.refreshToken(ctx, token)
+				var qerr *rderrors.QuotaExceededError
+				switch {
+				case err == nil:
+					backoff = 0
+				case errors.Is(err, rderrors.BadCredentialsError), errors.As(err, &qerr):
+					s.logger.Warn("stopping proactive token refresh", "status", rderrors.Classify(err))
+					return
+				case backoff == 0:
+					backoff = margin / 10
+				default:
+					backoff = min(2*backoff, margin)
+				}
+			}
+		}
+		timer.Reset(max(s.proactiveRefreshWait(time.Now(), margin), backoff))
+	}
+}
+
+// proactiveRefreshWait returns how long to wait before checking whether the token needs to be refreshed.
+func (s *raildataClient) proactiveRefreshWait(now time.Time, margin time.Duration) time.Duration {
+	s.tokenMutex.Lock()
+	defer s.tokenMutex.Unlock()
+	expiry, known := s.tokenExpiry()
+	if !known {
+		return time.Hour
+	}
+	// If the refresh is due but there are requests in progress, check again soon.
+	return max(expiry.Add(-margin).Sub(now), min(margin/10, time.Second))
+}
+
+// proactiveRefreshDue returns whether the token should be refreshed now, and the token to refresh.
+func (s *raildataClient) proactiveRefreshDue(now time.Time, margin time.Duration) (string, bool) {
+	if s.inflight.Load() > 0 || s.credentials == nil {
+		return "", false
+	}
+	s.tokenMutex.Lock()
+	defer s.tokenMutex.Unlock()
+	if s.tokenRefresh != nil || s.tokenLifetime <= margin {
+		return "", false
+	}
+	expiry, known := s.tokenExpiry()
+	if !known || now.Before(expiry.Add(-margin)) {
+		return "", false
+	}
+	return s.token, true
+}
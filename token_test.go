@@ -0,0 +1,259 @@
+package raildata_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jtarrio/raildata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newExpiringTokenServer returns a server that rejects "oldtoken" and only issues a new token when release is closed.
+func newExpiringTokenServer(t *testing.T, tokenRequests *atomic.Int32, release chan struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseMultipartForm(5000000))
+		switch req.URL.Path {
+		case "/getStationList":
+			if req.Form.Get("token") == "newtoken" {
+				expectRequest(t, "getStationList").sendJson(`[]`).ServeHTTP(rw, req)
+			} else {
+				expectRequest(t, "getStationList").sendError("Invalid token.").ServeHTTP(rw, req)
+			}
+		case "/getToken":
+			tokenRequests.Add(1)
+			<-release
+			expectRequest(t, "getToken").sendJson(`{"Authenticated": "True", "UserToken": "newtoken"}`).ServeHTTP(rw, req)
+		}
+	}))
+}
+
+func TestConcurrentRefreshesAreCoalesced(t *testing.T) {
+	var tokenRequests atomic.Int32
+	release := make(chan struct{})
+	server := newExpiringTokenServer(t, &tokenRequests, release)
+	defer server.Close()
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken("oldtoken"), raildata.WithCredentials("the-user-id", "the-password"))
+	require.NoError(t, err)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make([]error, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = client.GetStationList(context.Background())
+		}()
+	}
+	for tokenRequests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), tokenRequests.Load())
+	assert.Equal(t, "newtoken", client.GetToken())
+}
+
+func TestCancelledWaiterDoesNotCancelRefresh(t *testing.T) {
+	var tokenRequests atomic.Int32
+	release := make(chan struct{})
+	server := newExpiringTokenServer(t, &tokenRequests, release)
+	defer server.Close()
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken("oldtoken"), raildata.WithCredentials("the-user-id", "the-password"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelledErr := make(chan error)
+	go func() {
+		_, err := client.GetStationList(ctx)
+		cancelledErr <- err
+	}()
+	for tokenRequests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	assert.ErrorIs(t, <-cancelledErr, context.Canceled)
+
+	otherErr := make(chan error)
+	go func() {
+		_, err := client.GetStationList(context.Background())
+		otherErr <- err
+	}()
+	close(release)
+	assert.NoError(t, <-otherErr)
+	assert.Equal(t, int32(1), tokenRequests.Load())
+	assert.Equal(t, "newtoken", client.GetToken())
+}
+
+// tokenServer is a server that issues numbered tokens and only accepts the last one, until it's expired.
+type tokenServer struct {
+	*httptest.Server
+	mutex  sync.Mutex
+	issued int
+	valid  string
+}
+
+func newTokenServer(t *testing.T) *tokenServer {
+	ts := &tokenServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseMultipartForm(5000000))
+		ts.mutex.Lock()
+		defer ts.mutex.Unlock()
+		switch req.URL.Path {
+		case "/getToken":
+			ts.issued++
+			ts.valid = fmt.Sprintf("token-%d", ts.issued)
+			expectRequest(t, "getToken").sendJson(fmt.Sprintf(`{"Authenticated": "True", "UserToken": "%s"}`, ts.valid)).ServeHTTP(rw, req)
+		default:
+			if req.Form.Get("token") == ts.valid {
+				expectRequest(t, "getStationList").sendJson(`[]`).ServeHTTP(rw, req)
+			} else {
+				expectRequest(t, "getStationList").sendError("Invalid token.").ServeHTTP(rw, req)
+			}
+		}
+	}))
+	return ts
+}
+
+func (ts *tokenServer) expire() {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.valid = ""
+}
+
+func TestTokenInfo(t *testing.T) {
+	server := newTokenServer(t)
+	defer server.Close()
+
+	client, err := raildata.NewClient(withServerUrl(t, server.Server), raildata.WithToken("oldtoken"), raildata.WithCredentials("the-user-id", "the-password"))
+	require.NoError(t, err)
+
+	info := client.GetTokenInfo()
+	assert.Equal(t, raildata.TokenInfo{}, info)
+
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", client.GetToken())
+	info = client.GetTokenInfo()
+	require.NotNil(t, info.IssuedAt)
+	require.NotNil(t, info.LastValidated)
+	assert.False(t, info.LastValidated.Before(*info.IssuedAt))
+	assert.Nil(t, info.Lifetime)
+	assert.Nil(t, info.EstimatedExpiry)
+
+	time.Sleep(10 * time.Millisecond)
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+	server.expire()
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", client.GetToken())
+	info = client.GetTokenInfo()
+	require.NotNil(t, info.Lifetime)
+	assert.GreaterOrEqual(t, *info.Lifetime, 10*time.Millisecond)
+	require.NotNil(t, info.EstimatedExpiry)
+	assert.Equal(t, info.IssuedAt.Add(*info.Lifetime), *info.EstimatedExpiry)
+}
+
+func TestProactiveTokenRefresh(t *testing.T) {
+	server := newTokenServer(t)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, err := raildata.NewClient(
+		withServerUrl(t, server.Server),
+		raildata.WithToken("oldtoken"),
+		raildata.WithCredentials("the-user-id", "the-password"),
+		raildata.WithTokenLifetime(time.Hour),
+		raildata.WithProactiveTokenRefresh(ctx, time.Hour-50*time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", client.GetToken())
+	assert.Eventually(t, func() bool { return client.GetToken() == "token-2" }, 5*time.Second, 10*time.Millisecond)
+}
+
+// newFailingTokenServer returns a server that issues one token and then fails to issue more with the given response.
+func newFailingTokenServer(t *testing.T, tokenRequests *atomic.Int32, failure http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseMultipartForm(5000000))
+		switch req.URL.Path {
+		case "/getToken":
+			if tokenRequests.Add(1) == 1 {
+				expectRequest(t, "getToken").sendJson(`{"Authenticated": "True", "UserToken": "newtoken"}`).ServeHTTP(rw, req)
+			} else {
+				failure(rw, req)
+			}
+		default:
+			if req.Form.Get("token") == "newtoken" {
+				expectRequest(t, "getStationList").sendJson(`[]`).ServeHTTP(rw, req)
+			} else {
+				expectRequest(t, "getStationList").sendError("Invalid token.").ServeHTTP(rw, req)
+			}
+		}
+	}))
+}
+
+func TestProactiveTokenRefreshBacksOff(t *testing.T) {
+	var tokenRequests atomic.Int32
+	server := newFailingTokenServer(t, &tokenRequests, expectRequest(t, "getToken").sendResponse(503, ""))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, err := raildata.NewClient(
+		withServerUrl(t, server),
+		raildata.WithToken("oldtoken"),
+		raildata.WithCredentials("the-user-id", "the-password"),
+		raildata.WithQuotaLimit("getToken", 100),
+		raildata.WithTokenLifetime(time.Second),
+		raildata.WithProactiveTokenRefresh(ctx, 900*time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(1), tokenRequests.Load())
+
+	// The refresh is due after 100ms, and then it waits 90ms, 180ms, 360ms, 720ms... after each failure,
+	// so there are 4 refresh attempts in the first 1.2s.
+	time.Sleep(1200 * time.Millisecond)
+	assert.Greater(t, tokenRequests.Load(), int32(2))
+	assert.LessOrEqual(t, tokenRequests.Load(), int32(6))
+}
+
+func TestProactiveTokenRefreshStopsWithBadCredentials(t *testing.T) {
+	var tokenRequests atomic.Int32
+	server := newFailingTokenServer(t, &tokenRequests, expectRequest(t, "getToken").sendJson(`{"Authenticated": "False"}`))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, err := raildata.NewClient(
+		withServerUrl(t, server),
+		raildata.WithToken("oldtoken"),
+		raildata.WithCredentials("the-user-id", "the-password"),
+		raildata.WithTokenLifetime(200*time.Millisecond),
+		raildata.WithProactiveTokenRefresh(ctx, 150*time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	require.NoError(t, err)
+
+	time.Sleep(400 * time.Millisecond)
+	assert.Equal(t, int32(2), tokenRequests.Load())
+}
@@ -0,0 +1,99 @@
+package raildata
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"github.com/rogpeppe/go-internal/lockedfile"
+)
+
+// TokenStore persists the API token so it can be reused in later sessions or shared among several processes.
+//
+// Before requesting a new token, the client checks whether the stored token has changed; if it has, the client
+// uses the stored token instead of requesting a new one. This way, several processes that share the same store
+// don't request a new token each when the old one expires.
+//
+// Implementations must be safe for concurrent use.
+type TokenStore interface {
+	// Load returns the stored token, or an empty string if there is none.
+	Load() (string, error)
+	// CompareAndSwap replaces the stored token with newToken, but only if the stored token is oldToken.
+	// It returns whether the token was replaced.
+	CompareAndSwap(oldToken string, newToken string) (bool, error)
+}
+
+// NewMemoryTokenStore returns a [TokenStore] that keeps the token in memory, starting with the given token.
+func NewMemoryTokenStore(token string) TokenStore {
+	return &memoryTokenStore{token: token}
+}
+
+type memoryTokenStore struct {
+	mutex sync.Mutex
+	token string
+}
+
+func (t *memoryTokenStore) Load() (string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.token, nil
+}
+
+func (t *memoryTokenStore) CompareAndSwap(oldToken string, newToken string) (bool, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.token != oldToken {
+		return false, nil
+	}
+	t.token = newToken
+	return true, nil
+}
+
+// NewFileTokenStore returns a [TokenStore] that keeps the token in the first line of a file.
+// The file is locked while it's being read or updated, so several processes can share it.
+// A missing file is treated as an empty token.
+func NewFileTokenStore(name string) TokenStore {
+	return &fileTokenStore{name: name}
+}
+
+type fileTokenStore struct {
+	name string
+}
+
+func (t *fileTokenStore) Load() (string, error) {
+	b, err := lockedfile.Read(t.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return firstLine(b), nil
+}
+
+func (t *fileTokenStore) CompareAndSwap(oldToken string, newToken string) (bool, error) {
+	swapped := false
+	err := lockedfile.Transform(t.name, func(old []byte) ([]byte, error) {
+		if firstLine(old) != oldToken {
+			return old, nil
+		}
+		swapped = true
+		return []byte(newToken + "\n"), nil
+	})
+	return swapped, err
+}
+
+func firstLine(b []byte) string {
+	line, _, _ := strings.Cut(string(b), "\n")
+	return strings.TrimSpace(line)
+}
+
+// WithTokenStore sets a store to load the token from and save new tokens to.
+//
+// If the store contains a token, it takes precedence over the token passed with [WithToken].
+func WithTokenStore(store TokenStore) Option {
+	return func(s *raildataClient) {
+		s.tokenStore = store
+	}
+}
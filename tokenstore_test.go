@@ -0,0 +1,103 @@
+package raildata_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtarrio/raildata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "token")
+	store := raildata.NewFileTokenStore(name)
+
+	token, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "", token)
+
+	swapped, err := store.CompareAndSwap("", "first")
+	require.NoError(t, err)
+	assert.True(t, swapped)
+	swapped, err = store.CompareAndSwap("other", "second")
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	token, err = raildata.NewFileTokenStore(name).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "first", token)
+	b, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "first\n", string(b))
+}
+
+func TestTokenStoreProvidesInitialToken(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getStationList", "token", "stored").sendJson(`[]`))
+
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken("other"), raildata.WithTokenStore(raildata.NewMemoryTokenStore("stored")))
+	require.NoError(t, err)
+	assert.Equal(t, "stored", client.GetToken())
+
+	_, err = client.GetStationList(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestTokenStoreReceivesNewToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseMultipartForm(5000000))
+		switch req.URL.Path {
+		case "/getStationList":
+			if req.Form.Get("token") == "newtoken" {
+				expectRequest(t, "getStationList").sendJson(`[]`).ServeHTTP(rw, req)
+			} else {
+				expectRequest(t, "getStationList").sendError("Invalid token.").ServeHTTP(rw, req)
+			}
+		case "/getToken":
+			expectRequest(t, "getToken").sendJson(`{"Authenticated": "True", "UserToken": "newtoken"}`).ServeHTTP(rw, req)
+		}
+	}))
+
+	store := raildata.NewMemoryTokenStore("oldtoken")
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithTokenStore(store), raildata.WithCredentials("the-user-id", "the-password"))
+	require.NoError(t, err)
+
+	_, err = client.GetStationList(context.Background())
+	assert.NoError(t, err)
+	token, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "newtoken", token)
+}
+
+func TestTokenStoreAvoidsCreatingTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseMultipartForm(5000000))
+		switch req.URL.Path {
+		case "/getStationList":
+			if req.Form.Get("token") == "newtoken" {
+				expectRequest(t, "getStationList").sendJson(`[]`).ServeHTTP(rw, req)
+			} else {
+				expectRequest(t, "getStationList").sendError("Invalid token.").ServeHTTP(rw, req)
+			}
+		default:
+			assert.Fail(t, "unexpected request", req.URL.Path)
+		}
+	}))
+
+	store := raildata.NewMemoryTokenStore("oldtoken")
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithTokenStore(store), raildata.WithCredentials("the-user-id", "the-password"))
+	require.NoError(t, err)
+
+	// Another process updates the token.
+	swapped, err := store.CompareAndSwap("oldtoken", "newtoken")
+	require.NoError(t, err)
+	require.True(t, swapped)
+
+	_, err = client.GetStationList(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "newtoken", client.GetToken())
+}
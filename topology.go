@@ -0,0 +1,200 @@
+package raildata
+
+import (
+	"slices"
+	"strings"
+)
+
+// LineTopology describes the stations served by a line and the order in which trains visit them.
+type LineTopology struct {
+	// Line contains the line's code.
+	Line LineCode
+	// Routes contains the sequences of stations that trains on this line follow. Each route is ordered
+	// in the direction of westbound trains: from the terminal nearest New York or Hoboken (or Atlantic City,
+	// for the Atlantic City Line) outwards. Lines with several routes have branches; for example,
+	// Morris & Essex trains run to either New York or Hoboken.
+	Routes [][]StationCode
+	// BranchOf contains, for lines that are a branch of another line, the code of that line.
+	BranchOf LineCode
+	// Junction contains, for lines that are a branch of another line, the station where the branch splits off.
+	Junction StationCode
+}
+
+func stops(parts ...[]StationCode) []StationCode {
+	return slices.Concat(parts...)
+}
+
+var (
+	necStops      = []StationCode{"NP", "NA", "NZ", "EZ", "LI", "RH"}
+	necSouth      = []StationCode{"MP", "MU", "ED", "NB", "JA", "PJ", "HL", "TR"}
+	coastSouth    = []StationCode{"AV", "WB", "PE", "CH", "AM", "HZ", "MI", "RB", "LS", "MK", "LB", "EL", "AH", "AP", "BB", "BS", "LA", "SQ", "PP", "BH"}
+	meEast        = []StationCode{"ND", "EO", "BU", "OG", "HI", "MT", "SO", "MW", "MB", "RT", "ST"}
+	meWest        = []StationCode{"CM", "MA", "CN", "MR", "MX", "TB"}
+	meFar         = []StationCode{"DV", "DO", "HV", "HP", "NT", "OL", "HQ"}
+	gladstone     = []StationCode{"NV", "MH", "BY", "GI", "SG", "GO", "LY", "BI", "BV", "FH", "PC", "GL"}
+	montclair     = []StationCode{"ND", "WT", "BM", "GG", "MC", "WA", "WG", "UM", "MS", "HS", "UV", "GA", "FA", "23", "MV", "LP", "TO", "BN", "ML"}
+	mainLine      = []StationCode{"KG", "LN", "DL", "PS", "IF", "RN", "HW", "RS"}
+	bergenCounty  = []StationCode{"RF", "WM", "GD", "PL", "BF", "FZ", "GK"}
+	mainNorth     = []StationCode{"RW", "UF", "WK", "AZ", "RY", "17", "MZ", "SF"}
+	portJervis    = []StationCode{"XG", "TC", "RM", "CW", "CB", "MD", "OS", "PO"}
+	pascackValley = []StationCode{"WR", "TE", "EX", "AS", "NH", "RG", "OD", "EN", "WW", "HD", "WL", "PV", "ZM", "PQ", "NN", "SV"}
+	raritanValley = []StationCode{"NP", "US", "RL", "XC", "GW", "WF", "FW", "NE", "PF", "DN", "BK", "BW", "FE", "SM", "RA", "OR", "WH", "ON", "AN", "HG"}
+	atlanticCity  = []StationCode{"AC", "AB", "EH", "HN", "AO", "LW", "CY", "PN", "PH"}
+	amtrak        = []StationCode{"NY", "NP", "MP", "NB", "PJ", "TR", "NF", "PH", "WI", "BL", "BA", "NC", "WS"}
+	septa         = []StationCode{"TR", "NF", "PH", "WI"}
+
+	toNewYork = []StationCode{"NY", "SE"}
+	toHoboken = []StationCode{"HB"}
+)
+
+// LineTopologies contains the topology of every line in [Lines].
+var LineTopologies = []LineTopology{
+	{Line: "AC", Routes: [][]StationCode{atlanticCity}},
+	{Line: "MC", Routes: [][]StationCode{stops(toNewYork, montclair, meFar), stops(toHoboken, montclair, meFar)}},
+	{Line: "BC", Routes: [][]StationCode{stops(toHoboken, []StationCode{"TS"}, bergenCounty, mainNorth)}},
+	{Line: "ML", Routes: [][]StationCode{stops(toHoboken, []StationCode{"TS"}, mainLine, mainNorth, portJervis)}},
+	{Line: "ME", Routes: [][]StationCode{stops(toNewYork, meEast, meWest, meFar), stops(toHoboken, meEast, meWest, meFar)}},
+	{Line: "GS", Routes: [][]StationCode{stops(toNewYork, meEast, gladstone), stops(toHoboken, meEast, gladstone)}, BranchOf: "ME", Junction: "ST"},
+	{Line: "NE", Routes: [][]StationCode{stops(toNewYork, necStops, necSouth)}},
+	{Line: "NC", Routes: [][]StationCode{stops(toNewYork, necStops, coastSouth), stops(toHoboken, necStops, coastSouth)}},
+	{Line: "PV", Routes: [][]StationCode{stops(toHoboken, []StationCode{"TS"}, pascackValley)}},
+	{Line: "PR", Routes: [][]StationCode{{"PJ", "PR"}}, BranchOf: "NE", Junction: "PJ"},
+	{Line: "RV", Routes: [][]StationCode{raritanValley}},
+	{Line: "SL", Routes: [][]StationCode{{"HB", "TS", "XU"}}},
+	{Line: "AM", Routes: [][]StationCode{amtrak}},
+	{Line: "SP", Routes: [][]StationCode{septa}},
+}
+
+var lineTopologiesByCode = makeMap(LineTopologies, func(t *LineTopology) LineCode { return t.Line })
+
+// GetLineTopology returns the topology of the line with the given code, or false if it's not known.
+func GetLineTopology(line LineCode) (*LineTopology, bool) {
+	topology, found := lineTopologiesByCode[strings.ToLower(string(line))]
+	return topology, found
+}
+
+// Stations returns the stations served by the line, without repetitions, in the order of its routes.
+func (t *LineTopology) Stations() []StationCode {
+	var out []StationCode
+	for _, route := range t.Routes {
+		for _, station := range route {
+			if !slices.Contains(out, station) {
+				out = append(out, station)
+			}
+		}
+	}
+	return out
+}
+
+// Terminals returns the stations where the line's routes start or end.
+func (t *LineTopology) Terminals() []StationCode {
+	var out []StationCode
+	for _, route := range t.Routes {
+		for _, station := range []StationCode{route[0], route[len(route)-1]} {
+			if !slices.Contains(out, station) {
+				out = append(out, station)
+			}
+		}
+	}
+	return out
+}
+
+// Junctions returns the stations on the line where tracks split towards different destinations,
+// either on this line or on other lines.
+func (t *LineTopology) Junctions() []StationCode {
+	var out []StationCode
+	for _, station := range t.Stations() {
+		if len(networkNeighbors[station]) > 2 {
+			out = append(out, station)
+		}
+	}
+	return out
+}
+
+// Branches returns the codes of the lines that are branches of this line.
+func (t *LineTopology) Branches() []LineCode {
+	var out []LineCode
+	for i := range LineTopologies {
+		if LineTopologies[i].BranchOf == t.Line {
+			out = append(out, LineTopologies[i].Line)
+		}
+	}
+	return out
+}
+
+// StationsBetween returns the stations that a train on the given line visits when it travels from a to b,
+// including both, in the order it visits them. It returns nil if no route on the line serves both stations.
+func StationsBetween(line LineCode, a StationCode, b StationCode) []StationCode {
+	route, ia, ib := findRoute(line, a, b)
+	if route == nil {
+		return nil
+	}
+	if ia <= ib {
+		return slices.Clone(route[ia : ib+1])
+	}
+	out := slices.Clone(route[ib : ia+1])
+	slices.Reverse(out)
+	return out
+}
+
+// IsUpstream returns whether station a is upstream of station b on the given line;
+// that is, whether an eastbound train on that line visits a before b.
+// It returns false if no route on the line serves both stations.
+func IsUpstream(line LineCode, a StationCode, b StationCode) bool {
+	route, ia, ib := findRoute(line, a, b)
+	return route != nil && ia > ib
+}
+
+// LinesServing returns the codes of the lines that serve the given station.
+func LinesServing(station StationCode) []LineCode {
+	var out []LineCode
+	for i := range LineTopologies {
+		for _, route := range LineTopologies[i].Routes {
+			if slices.Contains(route, station) {
+				out = append(out, LineTopologies[i].Line)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// findRoute returns a route of the line that serves both stations, and the position of the stations in the route.
+func findRoute(line LineCode, a StationCode, b StationCode) ([]StationCode, int, int) {
+	topology, found := GetLineTopology(line)
+	if !found {
+		return nil, 0, 0
+	}
+	for _, route := range topology.Routes {
+		ia, ib := slices.Index(route, a), slices.Index(route, b)
+		if ia >= 0 && ib >= 0 {
+			return route, ia, ib
+		}
+	}
+	return nil, 0, 0
+}
+
+// networkNeighbors contains, for each station, the stations that are adjacent to it on any route.
+// Amtrak routes are not included because Amtrak trains skip most stations.
+var networkNeighbors = makeNetworkNeighbors()
+
+func makeNetworkNeighbors() map[StationCode][]StationCode {
+	out := map[StationCode][]StationCode{}
+	add := func(a StationCode, b StationCode) {
+		if !slices.Contains(out[a], b) {
+			out[a] = append(out[a], b)
+		}
+	}
+	for i := range LineTopologies {
+		if LineTopologies[i].Line == "AM" {
+			continue
+		}
+		for _, route := range LineTopologies[i].Routes {
+			for j := 1; j < len(route); j++ {
+				add(route[j-1], route[j])
+				add(route[j], route[j-1])
+			}
+		}
+	}
+	return out
+}
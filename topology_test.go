@@ -0,0 +1,72 @@
+package raildata_test
+
+import (
+	"testing"
+
+	"github.com/jtarrio/raildata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEveryLineHasTopology(t *testing.T) {
+	for _, line := range raildata.Lines {
+		topology, found := raildata.GetLineTopology(line.Code)
+		if !assert.True(t, found, "line %s", line.Code) {
+			continue
+		}
+		for _, station := range topology.Stations() {
+			_, found := raildata.FindStation().WithCode(station).Search()
+			assert.True(t, found, "line %s, station %s", line.Code, station)
+		}
+	}
+}
+
+func TestStationDetailsMatchTopology(t *testing.T) {
+	for _, details := range raildata.StationDetailsList {
+		assert.ElementsMatch(t, details.Lines, raildata.LinesServing(details.Code), "station %s", details.Code)
+	}
+}
+
+func TestLineTopologyBranches(t *testing.T) {
+	me, found := raildata.GetLineTopology("ME")
+	require.True(t, found)
+	assert.Equal(t, []raildata.LineCode{"GS"}, me.Branches())
+	assert.ElementsMatch(t, []raildata.StationCode{"NY", "HB", "HQ"}, me.Terminals())
+	assert.Subset(t, me.Junctions(), []raildata.StationCode{"SE", "ND", "ST", "DV"})
+	assert.NotContains(t, me.Junctions(), raildata.StationCode("MR"))
+
+	gs, _ := raildata.GetLineTopology("GS")
+	assert.Equal(t, raildata.LineCode("ME"), gs.BranchOf)
+	assert.Equal(t, raildata.StationCode("ST"), gs.Junction)
+
+	ne, _ := raildata.GetLineTopology("NE")
+	assert.Equal(t, []raildata.LineCode{"PR"}, ne.Branches())
+	assert.Contains(t, ne.Junctions(), raildata.StationCode("PJ"))
+}
+
+func TestStationsBetween(t *testing.T) {
+	assert.Equal(t, []raildata.StationCode{"NB", "JA", "PJ"}, raildata.StationsBetween("NE", "NB", "PJ"))
+	assert.Equal(t, []raildata.StationCode{"PJ", "JA", "NB"}, raildata.StationsBetween("NE", "PJ", "NB"))
+	assert.Equal(t, []raildata.StationCode{"ST", "NV", "MH"}, raildata.StationsBetween("GS", "ST", "MH"))
+	assert.Equal(t, []raildata.StationCode{"HB", "ND", "EO"}, raildata.StationsBetween("ME", "HB", "EO"))
+	assert.Equal(t, []raildata.StationCode{"NP"}, raildata.StationsBetween("NE", "NP", "NP"))
+	// New York and Hoboken are on different routes.
+	assert.Nil(t, raildata.StationsBetween("ME", "NY", "HB"))
+	assert.Nil(t, raildata.StationsBetween("NE", "NB", "GL"))
+	assert.Nil(t, raildata.StationsBetween("XX", "NB", "PJ"))
+}
+
+func TestIsUpstream(t *testing.T) {
+	assert.True(t, raildata.IsUpstream("NE", "TR", "NY"))
+	assert.False(t, raildata.IsUpstream("NE", "NY", "TR"))
+	assert.False(t, raildata.IsUpstream("NE", "NY", "NY"))
+	assert.True(t, raildata.IsUpstream("GS", "GL", "ST"))
+	assert.True(t, raildata.IsUpstream("AC", "PH", "AC"))
+	assert.False(t, raildata.IsUpstream("NE", "TR", "GL"))
+}
+
+func TestLinesServing(t *testing.T) {
+	assert.Equal(t, []raildata.LineCode{"ME", "GS"}, raildata.LinesServing("ST"))
+	assert.Equal(t, []raildata.LineCode{"BC", "ML", "PV", "SL"}, raildata.LinesServing("TS"))
+	assert.Empty(t, raildata.LinesServing("SC"))
+}
@@ -0,0 +1,188 @@
+package raildata
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/jtarrio/raildata/api"
+	rderrors "github.com/jtarrio/raildata/errors"
+)
+
+// Attribute keys used in the spans created by the client.
+const (
+	TraceAttrMethod         = "raildata.method"           // name of the RailData API method.
+	TraceAttrStation        = "raildata.station"          // station code in the request.
+	TraceAttrLine           = "raildata.line"             // line code in the request.
+	TraceAttrTrainId        = "raildata.train_id"         // train id in the request.
+	TraceAttrCached         = "raildata.cached"           // whether the response came from the cache.
+	TraceAttrRetryCount     = "raildata.retry_count"      // number of retries made.
+	TraceAttrTokenRefreshed = "raildata.token_refreshed"  // whether the token had to be refreshed.
+	TraceAttrErrorClass     = "raildata.error_class"      // class of the error, as returned by errors.Classify.
+	TraceAttrHttpStatus     = "http.response.status_code" // HTTP status code of the last response.
+)
+
+// TraceAttribute contains an attribute of a span. Its value is a string, an int, or a bool.
+type TraceAttribute struct {
+	Key   string
+	Value any
+}
+
+// Tracer is the interface that the client uses to report the calls it makes to the RailData API.
+//
+// The client starts a span for every call to a RailData API method, and another one when it refreshes the token.
+// Use the [github.com/jtarrio/raildata/otel] module to report the spans to OpenTelemetry.
+type Tracer interface {
+	// Start starts a span with the given name and attributes. The returned context contains the span,
+	// so spans started with it are its children.
+	Start(ctx context.Context, name string, attributes ...TraceAttribute) (context.Context, Span)
+}
+
+// Span is the interface for a span started by a [Tracer].
+type Span interface {
+	// SetAttributes adds or replaces attributes in the span.
+	SetAttributes(attributes ...TraceAttribute)
+	// AddEvent records an event in the span.
+	AddEvent(name string, attributes ...TraceAttribute)
+	// End finishes the span. The error is nil if the call succeeded.
+	End(err error)
+}
+
+// WithTracer makes the client report its calls to the RailData API to the given tracer.
+func WithTracer(tracer Tracer) Option {
+	return func(s *raildataClient) {
+		s.tracer = tracer
+	}
+}
+
+// callSpan contains the span for a call in progress and its counters.
+//
+// The span may be nil, to collect the counters of a call that is shared by several callers. See [coalesce].
+type callSpan struct {
+	span Span
+
+	mutex          sync.Mutex
+	retries        int
+	tokenRefreshed bool
+	httpStatus     int
+}
+
+type callSpanKey struct{}
+
+// startSpan starts a span for a call to the given method, if there is a tracer.
+func (s *raildataClient) startSpan(ctx context.Context, method string, input any) (context.Context, *callSpan) {
+	if s.tracer == nil {
+		return ctx, nil
+	}
+	ctx, span := s.tracer.Start(ctx, "raildata."+method, append([]TraceAttribute{{TraceAttrMethod, method}}, requestAttributes(input)...)...)
+	cs := &callSpan{span: span}
+	return context.WithValue(ctx, callSpanKey{}, cs), cs
+}
+
+// spanFromContext returns the span for the call in progress, or nil if there isn't one.
+func spanFromContext(ctx context.Context) *callSpan {
+	cs, _ := ctx.Value(callSpanKey{}).(*callSpan)
+	return cs
+}
+
+func (cs *callSpan) retried(attempt int, err error) {
+	if cs == nil {
+		return
+	}
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	cs.retries++
+	cs.addEvent("retry", TraceAttribute{"attempt", attempt}, TraceAttribute{TraceAttrErrorClass, rderrors.Classify(err)})
+}
+
+func (cs *callSpan) refreshingToken() {
+	if cs == nil {
+		return
+	}
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	cs.tokenRefreshed = true
+	cs.addEvent("token refresh")
+}
+
+func (cs *callSpan) responded(err error) {
+	if cs == nil {
+		return
+	}
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	var herr *rderrors.HttpStatusError
+	switch {
+	case err == nil:
+		cs.httpStatus = 200
+	case errors.As(err, &herr):
+		cs.httpStatus = herr.StatusCode
+	}
+}
+
+// absorb adds the counters collected for a shared call to this span.
+func (cs *callSpan) absorb(shared *callSpan) {
+	if cs == nil {
+		return
+	}
+	shared.mutex.Lock()
+	retries, tokenRefreshed, httpStatus := shared.retries, shared.tokenRefreshed, shared.httpStatus
+	shared.mutex.Unlock()
+
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	cs.retries += retries
+	cs.tokenRefreshed = cs.tokenRefreshed || tokenRefreshed
+	if httpStatus != 0 {
+		cs.httpStatus = httpStatus
+	}
+}
+
+func (cs *callSpan) addEvent(name string, attributes ...TraceAttribute) {
+	if cs.span != nil {
+		cs.span.AddEvent(name, attributes...)
+	}
+}
+
+func (cs *callSpan) end(cached bool, err error) {
+	if cs == nil {
+		return
+	}
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	attributes := []TraceAttribute{
+		{TraceAttrCached, cached},
+		{TraceAttrRetryCount, cs.retries},
+		{TraceAttrTokenRefreshed, cs.tokenRefreshed},
+		{TraceAttrErrorClass, rderrors.Classify(err)},
+	}
+	if cs.httpStatus != 0 {
+		attributes = append(attributes, TraceAttribute{TraceAttrHttpStatus, cs.httpStatus})
+	}
+	cs.span.SetAttributes(attributes...)
+	cs.span.End(err)
+}
+
+func requestAttributes(input any) []TraceAttribute {
+	var out []TraceAttribute
+	add := func(key string, value string) {
+		if len(value) > 0 {
+			out = append(out, TraceAttribute{key, value})
+		}
+	}
+	switch req := input.(type) {
+	case *api.GetStationMsgRequest:
+		add(TraceAttrStation, req.Station)
+		add(TraceAttrLine, req.Line)
+	case *api.GetStationScheduleRequest:
+		add(TraceAttrStation, req.Station)
+	case *api.GetTrainScheduleRequest:
+		add(TraceAttrStation, req.Station)
+	case *api.GetTrainSchedule19RecRequest:
+		add(TraceAttrStation, req.Station)
+		add(TraceAttrLine, req.Line)
+	case *api.GetTrainStopListRequest:
+		add(TraceAttrTrainId, req.Train)
+	}
+	return out
+}
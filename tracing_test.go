@@ -0,0 +1,103 @@
+package raildata_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jtarrio/raildata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSpan struct {
+	mutex      sync.Mutex
+	name       string
+	attributes map[string]any
+	events     []string
+	ended      bool
+	err        error
+}
+
+func (s *fakeSpan) SetAttributes(attributes ...raildata.TraceAttribute) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, a := range attributes {
+		s.attributes[a.Key] = a.Value
+	}
+}
+
+func (s *fakeSpan) AddEvent(name string, attributes ...raildata.TraceAttribute) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, name)
+}
+
+func (s *fakeSpan) End(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ended = true
+	s.err = err
+}
+
+type fakeTracer struct {
+	mutex sync.Mutex
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string, attributes ...raildata.TraceAttribute) (context.Context, raildata.Span) {
+	span := &fakeSpan{name: name, attributes: map[string]any{}}
+	span.SetAttributes(attributes...)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestTracerSeesCachedCalls(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getTrainSchedule", "station", "NY").sendJson(`{"STATION_2CHAR": "NY", "STATIONNAME": "New York", "ITEMS": []}`))
+	defer server.Close()
+
+	tracer := &fakeTracer{}
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithCache(raildata.NewMemoryCache(10)), raildata.WithTracer(tracer))
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+		require.NoError(t, err)
+	}
+
+	require.Len(t, tracer.spans, 2)
+	for i, cached := range []bool{false, true} {
+		span := tracer.spans[i]
+		assert.Equal(t, "raildata.getTrainSchedule", span.name)
+		assert.True(t, span.ended)
+		assert.NoError(t, span.err)
+		assert.Equal(t, "NY", span.attributes[raildata.TraceAttrStation])
+		assert.Equal(t, cached, span.attributes[raildata.TraceAttrCached])
+		assert.Equal(t, 0, span.attributes[raildata.TraceAttrRetryCount])
+		assert.Equal(t, false, span.attributes[raildata.TraceAttrTokenRefreshed])
+	}
+	assert.Equal(t, 200, tracer.spans[0].attributes[raildata.TraceAttrHttpStatus])
+	assert.NotContains(t, tracer.spans[1].attributes, raildata.TraceAttrHttpStatus)
+}
+
+func TestTracerRecordsStatusOfInvalidToken(t *testing.T) {
+	server := httptest.NewServer(expectRequest(t, "getTrainSchedule").sendResponse(401, `{"errorMessage": "Invalid token."}`))
+	defer server.Close()
+
+	tracer := &fakeTracer{}
+	client, err := raildata.NewClient(withServerUrl(t, server), raildata.WithToken(testToken), raildata.WithTracer(tracer))
+	require.NoError(t, err)
+
+	_, err = client.GetTrainSchedule(context.Background(), &raildata.GetTrainScheduleRequest{StationCode: "NY"})
+	require.Error(t, err)
+
+	require.NotEmpty(t, tracer.spans)
+	span := tracer.spans[0]
+	assert.Equal(t, "raildata.getTrainSchedule", span.name)
+	assert.Equal(t, 401, span.attributes[raildata.TraceAttrHttpStatus])
+	assert.Equal(t, true, span.attributes[raildata.TraceAttrTokenRefreshed])
+	assert.Error(t, span.err)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -12,6 +13,8 @@ import (
 type Client interface {
 	// GetToken returns the token currently being used by the client.
 	GetToken() string
+	// GetTokenInfo returns what the client knows about the token it is currently using.
+	GetTokenInfo() TokenInfo
 	// GetStationList returns a list of all stations, with their codes, names, and short names.
 	GetStationList(context.Context) (*GetStationListResponse, error)
 	// GetStationMsg returns a list of messages and alerts, optionally scoped to one station and/or one line.
@@ -26,7 +29,7 @@ type Client interface {
 	// This method does not return information about each train's stops. You can use GetTrainStopList to retrieve it.
 	GetTrainSchedule19Records(context.Context, *GetTrainSchedule19RecordsRequest) (*GetTrainScheduleResponse, error)
 	// GetTrainStopList returns the list of stops for a train.
-	// Returns nil if the provided train id is not valid.
+	// Returns a [github.com/jtarrio/raildata/errors.TrainNotFoundError] if the provided train id is not valid.
 	GetTrainStopList(context.Context, *GetTrainStopListRequest) (*GetTrainStopListResponse, error)
 	// GetVehicleData returns the position and status for all active trains.
 	//
@@ -34,6 +37,12 @@ type Client interface {
 	GetVehicleData(context.Context) (*GetVehicleDataResponse, error)
 	// RateLimitedMethods returns an interface for rate-limited operations.
 	RateLimitedMethods() RateLimitedMethods
+	// Raw returns an interface whose methods return the responses from the RailData API along with the enriched responses.
+	Raw() RawClient
+	// GetQuotaUsage returns the number of calls made today to each method that has a daily usage limit.
+	//
+	// This method doesn't contact the RailData server; it returns the counts kept by the client.
+	GetQuotaUsage() ([]QuotaUsage, error)
 }
 
 // RateLimitedMethods contains methods you can only call a few times per day.
@@ -58,12 +67,16 @@ type IsValidTokenResponse struct {
 	ValidToken bool
 	// UserId contains the user id for this token, if found.
 	UserId *string
+	// Info contains information about how this response was obtained.
+	Info ResponseInfo
 }
 
 // GetStationListResponse contains the result of the GetStationList method.
 type GetStationListResponse struct {
 	// Stations contains the list of stations.
 	Stations []Station
+	// Info contains information about how this response was obtained.
+	Info ResponseInfo
 }
 
 // GetStationMsgRequest contains the arguments of the GetStationMsg method.
@@ -78,6 +91,8 @@ type GetStationMsgRequest struct {
 type GetStationMsgResponse struct {
 	// Messages contains the list of messages.
 	Messages []StationMsg
+	// Info contains information about how this response was obtained.
+	Info ResponseInfo
 }
 
 // GetStationScheduleRequest contains the arguments of the GetStationSchedule method.
@@ -93,6 +108,8 @@ type GetStationScheduleRequest struct {
 type GetStationScheduleResponse struct {
 	// Entries contains the schedule entries.
 	Entries []StationSchedule
+	// Info contains information about how this response was obtained.
+	Info ResponseInfo
 }
 
 // GetTrainScheduleRequest contains the arguments of the GetTrainSchedule method.
@@ -117,6 +134,8 @@ type GetTrainScheduleResponse struct {
 	Messages []StationMsg
 	// Entries contains the schedule entries.
 	Entries []TrainScheduleEntry
+	// Info contains information about how this response was obtained.
+	Info ResponseInfo
 }
 
 // GetTrainStopListRequest contains the arguments of the GetTrainStopList method.
@@ -143,12 +162,34 @@ type GetTrainStopListResponse struct {
 	Stops []TrainStop
 	// Capacity contains information on how full this train is.
 	Capacity []TrainCapacity
+	// Info contains information about how this response was obtained.
+	Info ResponseInfo
 }
 
 // GetVehicleDataResponse contains the result of the GetVehicleData method.
 type GetVehicleDataResponse struct {
 	// Vehicles contains a list of active trains.
 	Vehicles []VehicleData
+	// Info contains information about how this response was obtained.
+	Info ResponseInfo
+}
+
+// ResponseInfo contains information about how a response was obtained.
+type ResponseInfo struct {
+	// Cached indicates, if true, that the response was served from the cache instead of the RailData server.
+	Cached bool
+	// Age contains how long ago the response was received from the RailData server. It is zero for fresh responses.
+	Age time.Duration
+	// Stale indicates, if true, that the call to the RailData server failed and the client returned an older response instead.
+	// See [WithStaleFallback].
+	Stale bool
+	// Warnings contains the fields in the response that could not be parsed.
+	Warnings []ParseWarning
+}
+
+func (i ResponseInfo) withWarnings(warnings []ParseWarning) ResponseInfo {
+	i.Warnings = warnings
+	return i
 }
 
 // Location contains a vehicle's GPS location.
@@ -253,6 +294,11 @@ type TrainScheduleEntry struct {
 	Stops []TrainStop
 }
 
+// IsCancelled returns whether the train's status says that it has been cancelled.
+func (e *TrainScheduleEntry) IsCancelled() bool {
+	return isCancelledStatus(e.Status)
+}
+
 // TrainCapacity contains information on how full a train is.
 type TrainCapacity struct {
 	// Number contains the train's number.
@@ -269,10 +315,29 @@ type TrainCapacity struct {
 	CapacityColor Color
 	// PassengerCount contains the number of passengers on board the train.
 	PassengerCount int
+	// PreviousCapacityPercent contains the percentage of capacity used in the previous reading, if known.
+	PreviousCapacityPercent *int
+	// PreviousCapacityColor contains the color that represents how full the train was in the previous reading, if known.
+	PreviousCapacityColor *Color
+	// PreviousPassengerCount contains the number of passengers on board the train in the previous reading, if known.
+	PreviousPassengerCount *int
+	// Trend contains how the train's occupancy changed since the previous reading, if known.
+	Trend *CapacityTrend
 	// Sections contains capacity information for each train section.
+	// The RailData API doesn't provide previous readings for sections or cars.
 	Sections []TrainSection
 }
 
+// CapacityTrend contains how a train's occupancy changed between two readings.
+type CapacityTrend struct {
+	// Direction contains whether the train is filling up or emptying.
+	Direction TrendDirection
+	// PassengerDelta contains the change in the number of passengers. It is negative if the train is emptying.
+	PassengerDelta int
+	// PercentDelta contains the change in the percentage of capacity used, if known.
+	PercentDelta *int
+}
+
 // TrainSection contains information on how full a train section is.
 type TrainSection struct {
 	// Position contains the section's position on the train.
@@ -323,6 +388,17 @@ type TrainStop struct {
 	StopLines []StopLine
 }
 
+// IsCancelled returns whether the stop's status says that the train won't stop at this station.
+func (s *TrainStop) IsCancelled() bool {
+	return isCancelledStatus(s.StopStatus)
+}
+
+// isCancelledStatus returns whether a train or stop status means that it has been cancelled.
+// The RailData API uses several spellings, such as "CANCELLED" and "Cancelled".
+func isCancelledStatus(status *string) bool {
+	return status != nil && strings.Contains(strings.ToUpper(*status), "CANCEL")
+}
+
 // StopLine contains information about a connecting line.
 type StopLine struct {
 	// Line contain's the line that connects at this stop.
@@ -378,6 +454,15 @@ const (
 	SectionPositionBack                          // the last cars in a train.
 )
 
+// TrendDirection represents how a train's occupancy is changing.
+type TrendDirection int
+
+const (
+	TrendSteady   TrendDirection = iota // the number of passengers didn't change.
+	TrendFilling                        // the number of passengers increased.
+	TrendEmptying                       // the number of passengers decreased.
+)
+
 // ColorSet contains colors used to render a line name.
 type ColorSet struct {
 	// Foreground contains the color for the text.
@@ -40,3 +40,13 @@ func TestColor(t *testing.T) {
 		assert.Error(t, err)
 	}
 }
+
+func TestIsCancelled(t *testing.T) {
+	assert.True(t, (&raildata.TrainScheduleEntry{Status: ptr("CANCELLED")}).IsCancelled())
+	assert.True(t, (&raildata.TrainScheduleEntry{Status: ptr("Cancelled")}).IsCancelled())
+	assert.False(t, (&raildata.TrainScheduleEntry{Status: ptr("On Time")}).IsCancelled())
+	assert.False(t, (&raildata.TrainScheduleEntry{}).IsCancelled())
+	assert.True(t, (&raildata.TrainStop{StopStatus: ptr("Cancelled")}).IsCancelled())
+	assert.False(t, (&raildata.TrainStop{StopStatus: ptr("OnTime")}).IsCancelled())
+	assert.False(t, (&raildata.TrainStop{}).IsCancelled())
+}